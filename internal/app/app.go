@@ -2,11 +2,17 @@ package app
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/alfonzm/lazydb/internal/ui"
 )
 
-func Start() int {
+func Start(args []string) int {
+	// Headless mode, e.g. `lazydb exec -c prod "SELECT 1"`
+	if len(args) > 0 && args[0] == "exec" {
+		return Exec(args[1:], os.Stdin, os.Stdout, os.Stderr)
+	}
+
 	if err := ui.Start(); err != nil {
 		fmt.Println(err)
 		return 1
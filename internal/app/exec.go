@@ -0,0 +1,220 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alfonzm/lazydb/internal/config"
+	"github.com/alfonzm/lazydb/internal/db"
+)
+
+const execUsage = `Usage: lazydb exec -c <connection> [-f table|csv|json] [SQL]
+
+Runs a single SQL statement against a connection from lazydb.yml and prints
+the result to stdout. The statement is read from stdin when omitted or "-".
+`
+
+// Exec runs the headless `lazydb exec` command and returns the exit code:
+// 0 on success, 1 on connection/SQL errors and 2 on usage errors
+func Exec(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("exec", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, execUsage)
+		flags.PrintDefaults()
+	}
+
+	connectionName := flags.String("c", "", "name of the connection in lazydb.yml")
+	format := flags.String("f", "table", "output format: table, csv or json")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if *connectionName == "" {
+		fmt.Fprintln(stderr, "A connection is required (-c)")
+		flags.Usage()
+		return 2
+	}
+
+	switch *format {
+	case "table", "csv", "json":
+	default:
+		fmt.Fprintf(stderr, "Unknown output format %q\n", *format)
+		return 2
+	}
+
+	query := strings.Join(flags.Args(), " ")
+	if query == "" || query == "-" {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to read query from stdin: %v\n", err)
+			return 1
+		}
+		query = string(input)
+	}
+
+	if strings.TrimSpace(query) == "" {
+		fmt.Fprintln(stderr, "No query given")
+		return 2
+	}
+
+	connection, err := config.GetConnection(*connectionName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	client, err := db.NewDBClient(connection.String(), connection.ReadOnly)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer client.Close()
+
+	result, err := client.RunQuery(query)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	switch *format {
+	case "csv":
+		err = writeCSV(stdout, result)
+	case "json":
+		err = writeJSON(stdout, result)
+	default:
+		err = writeTable(stdout, result)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to write output: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func writeTable(w io.Writer, result *db.QueryResult) error {
+	if result.Columns == nil {
+		_, err := fmt.Fprintf(w, "%d rows affected\n", result.RowsAffected)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	separators := make([]string, len(result.Columns))
+	for i, column := range result.Columns {
+		separators[i] = strings.Repeat("-", len(column))
+	}
+
+	fmt.Fprintln(tw, strings.Join(result.Columns, "\t"))
+	fmt.Fprintln(tw, strings.Join(separators, "\t"))
+
+	for _, row := range result.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			if value == nil {
+				cells[i] = "NULL"
+			} else {
+				// tabs and newlines would break the alignment
+				cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(value.(string))
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, result *db.QueryResult) error {
+	if result.Columns == nil {
+		_, err := fmt.Fprintf(w, "rows_affected\n%d\n", result.RowsAffected)
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(result.Columns); err != nil {
+		return err
+	}
+
+	for _, row := range result.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value != nil {
+				record[i] = value.(string)
+			}
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// writeJSON writes the rows as an array of objects, keeping the column order
+// of the result set (encoding/json would sort map keys)
+func writeJSON(w io.Writer, result *db.QueryResult) error {
+	if result.Columns == nil {
+		_, err := fmt.Fprintf(w, "{\"rows_affected\":%d}\n", result.RowsAffected)
+		return err
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("[")
+
+	for i, row := range result.Rows {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+
+		sb.WriteString("\n  {")
+
+		for j, column := range result.Columns {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+
+			key, err := json.Marshal(column)
+			if err != nil {
+				return err
+			}
+
+			value, err := json.Marshal(row[j])
+			if err != nil {
+				return err
+			}
+
+			sb.Write(key)
+			sb.WriteString(":")
+			sb.Write(value)
+		}
+
+		sb.WriteString("}")
+	}
+
+	if len(result.Rows) > 0 {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("]\n")
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,15 +18,29 @@ type Connection struct {
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	Database string `yaml:"database"`
+	ReadOnly bool   `yaml:"read_only,omitempty"`
+	Color    string `yaml:"color,omitempty"`
+	Label    string `yaml:"label,omitempty"`
 }
 
 type Config struct {
 	Connections map[string]Connection `yaml:"connections"`
 }
 
+// Path returns the location of the lazydb config file
+func Path() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "lazydb.yml")
+}
+
+// Exists reports whether the config file has been created
+func Exists() bool {
+	_, err := os.Stat(Path())
+	return err == nil
+}
+
 func readConfig() (*Config, error) {
 	// Open the file
-	filePath := filepath.Join(os.Getenv("HOME"), ".config", "lazydb.yml")
+	filePath := Path()
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("Error opening config file, make sure it exists at %s", filePath)
@@ -49,6 +67,255 @@ func GetConnections() (map[string]Connection, error) {
 	return config.Connections, nil
 }
 
+// GetConnection returns the connection with the given name from the config
+func GetConnection(name string) (Connection, error) {
+	connections, err := GetConnections()
+	if err != nil {
+		return Connection{}, err
+	}
+
+	connection, ok := connections[name]
+	if !ok {
+		return Connection{}, fmt.Errorf("Connection %q not found in config", name)
+	}
+
+	return connection, nil
+}
+
+// SaveConnection writes the connection to the config file under the given
+// name. When oldName is set the existing entry is updated (and renamed if the
+// name changed), otherwise a new entry is added. Comments and the order of
+// the other entries are preserved.
+func SaveConnection(oldName string, name string, connection Connection) error {
+	if name == "" {
+		return fmt.Errorf("Connection name is required")
+	}
+
+	return updateConnections(func(connections *yaml.Node) error {
+		if name != oldName && mappingValue(connections, name) != nil {
+			return fmt.Errorf("Connection %q already exists", name)
+		}
+
+		var value yaml.Node
+		if err := value.Encode(connection); err != nil {
+			return err
+		}
+
+		if oldName != "" {
+			for i := 0; i < len(connections.Content); i += 2 {
+				if connections.Content[i].Value == oldName {
+					connections.Content[i].Value = name
+					mergeConnection(connections.Content[i+1], &value)
+					return nil
+				}
+			}
+		}
+
+		connections.Content = append(
+			connections.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+			&value,
+		)
+
+		return nil
+	})
+}
+
+// DeleteConnection removes the connection with the given name from the config file
+func DeleteConnection(name string) error {
+	return updateConnections(func(connections *yaml.Node) error {
+		for i := 0; i < len(connections.Content); i += 2 {
+			if connections.Content[i].Value == name {
+				connections.Content = append(connections.Content[:i], connections.Content[i+2:]...)
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Connection %q not found in config", name)
+	})
+}
+
+// updateConnections loads the config file as a YAML node tree so comments
+// survive, lets update modify the connections mapping, and atomically
+// writes the result back
+func updateConnections(update func(connections *yaml.Node) error) error {
+	filePath := Path()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("Failed to parse config file: %w", err)
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("Config file %s is not a YAML mapping", filePath)
+	}
+
+	connections := mappingValue(root, "connections")
+	if connections == nil {
+		connections = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(
+			root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "connections"},
+			connections,
+		)
+	}
+
+	// an empty `connections:` key is decoded as null
+	if connections.Kind == yaml.ScalarNode && connections.Tag == "!!null" {
+		connections.Kind = yaml.MappingNode
+		connections.Tag = "!!map"
+		connections.Value = ""
+	}
+
+	if connections.Kind != yaml.MappingNode {
+		return fmt.Errorf("connections in %s is not a YAML mapping", filePath)
+	}
+
+	if err := update(connections); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectIndent(content))
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("Failed to encode config file: %w", err)
+	}
+	encoder.Close()
+
+	return writeFileAtomic(filePath, buf.Bytes())
+}
+
+// mergeConnection copies the fields of src into the existing dst mapping,
+// keeping the comments attached to fields that already exist. Connection
+// fields that are no longer set are removed, unknown keys are left alone.
+func mergeConnection(dst *yaml.Node, src *yaml.Node) {
+	if dst.Kind != yaml.MappingNode {
+		*dst = *src
+		return
+	}
+
+	for i := 0; i < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		existing := mappingValue(dst, key.Value)
+		if existing == nil {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+
+		value.HeadComment = existing.HeadComment
+		value.LineComment = existing.LineComment
+		value.FootComment = existing.FootComment
+		*existing = *value
+	}
+
+	knownFields := connectionFields()
+
+	for i := 0; i < len(dst.Content); {
+		key := dst.Content[i].Value
+		if knownFields[key] && mappingValue(src, key) == nil {
+			dst.Content = append(dst.Content[:i], dst.Content[i+2:]...)
+			continue
+		}
+		i += 2
+	}
+}
+
+// connectionFields returns the YAML keys of the Connection struct
+func connectionFields() map[string]bool {
+	fields := map[string]bool{}
+
+	connectionType := reflect.TypeOf(Connection{})
+	for i := 0; i < connectionType.NumField(); i++ {
+		name := strings.Split(connectionType.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+
+	return fields
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+var indentedLine = regexp.MustCompile(`(?m)^( +)\S`)
+
+// detectIndent returns the indentation of the first indented line so the
+// rewritten file keeps the user's style, defaulting to 2 spaces
+func detectIndent(content []byte) int {
+	match := indentedLine.FindSubmatch(content)
+	if match == nil {
+		return 2
+	}
+
+	return len(match[1])
+}
+
+// writeFileAtomic writes to a temporary file next to the target and renames
+// it over the target, so a crash never leaves a half-written config behind
+func writeFileAtomic(filePath string, content []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("Failed to create config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".lazydb.yml.*")
+	if err != nil {
+		return fmt.Errorf("Failed to write config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write config file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write config file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Failed to write config file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("Failed to write config file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("Failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
 func (c *Connection) String() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.User, c.Password, c.Host, c.Port, c.Database)
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TabSession is the state of a tab to restore on the next start
+type TabSession struct {
+	Name          string   `yaml:"name,omitempty"`
+	Connection    string   `yaml:"connection,omitempty"`
+	Database      string   `yaml:"database,omitempty"`
+	Table         string   `yaml:"table,omitempty"`
+	Where         string   `yaml:"where,omitempty"`
+	HiddenColumns []string `yaml:"hidden_columns,omitempty"`
+	Page          string   `yaml:"page,omitempty"`
+	Query         string   `yaml:"query,omitempty"`
+
+	// Sorts is the sort of every table sorted in the tab, by table name
+	Sorts map[string][]SortColumn `yaml:"sorts,omitempty"`
+}
+
+// SortColumn is a column of a sort, in order of priority
+type SortColumn struct {
+	Name      string `yaml:"name"`
+	Ascending bool   `yaml:"ascending"`
+}
+
+// Session is the list of open tabs, saved on quit
+type Session struct {
+	Tabs       []TabSession `yaml:"tabs"`
+	CurrentTab int          `yaml:"current_tab"`
+}
+
+// SessionPath returns the location of the session file
+func SessionPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "lazydb-session.yml")
+}
+
+// LoadSession reads the session file, it returns nil if there is none
+func LoadSession() (*Session, error) {
+	content, err := os.ReadFile(SessionPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read session file: %w", err)
+	}
+
+	var session Session
+	if err := yaml.Unmarshal(content, &session); err != nil {
+		return nil, fmt.Errorf("Failed to parse session file: %w", err)
+	}
+
+	return &session, nil
+}
+
+// SaveSession writes the session file
+func SaveSession(session Session) error {
+	content, err := yaml.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("Failed to encode session file: %w", err)
+	}
+
+	return writeFileAtomic(SessionPath(), content)
+}
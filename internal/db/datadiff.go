@@ -0,0 +1,277 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxDiffRows is the number of rows of a table that can be compared, the
+// rows of both tables are loaded in memory
+const maxDiffRows = 50000
+
+type RowStatus string
+
+const (
+	RowAdded   RowStatus = "added"   // only in the source table
+	RowRemoved RowStatus = "removed" // only in the target table
+	RowChanged RowStatus = "changed"
+)
+
+// RowDiff is a row that differs between the source and the target table.
+// Values are strings or nil for NULL, Source is nil for removed rows and
+// Target is nil for added rows.
+type RowDiff struct {
+	Status  RowStatus
+	Source  map[string]any
+	Target  map[string]any
+	Changed []string
+}
+
+// DataDiff is the result of comparing the rows of two tables matched by
+// primary key
+type DataDiff struct {
+	// Columns are in both tables, the others are listed in Ignored
+	Columns    []string
+	Ignored    []string
+	PrimaryKey []string
+	Rows       []RowDiff
+	Same       int
+	binary     map[string]bool
+}
+
+// Counts returns the number of added, removed and changed rows
+func (d *DataDiff) Counts() (int, int, int) {
+	var added, removed, changed int
+
+	for _, row := range d.Rows {
+		switch row.Status {
+		case RowAdded:
+			added++
+		case RowRemoved:
+			removed++
+		case RowChanged:
+			changed++
+		}
+	}
+
+	return added, removed, changed
+}
+
+// IsKeyColumn reports whether the column is part of the primary key
+func (d *DataDiff) IsKeyColumn(column string) bool {
+	return slices.Contains(d.PrimaryKey, column)
+}
+
+// DiffData compares the rows of the source table with the target table,
+// which can be on another connection. Rows are matched by the primary key
+// of the source table.
+func DiffData(source *DBClient, sourceTable string, target *DBClient, targetTable string) (*DataDiff, error) {
+	sourceColumns, err := source.GetColumns(QuoteIdentifier(sourceTable))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get columns of %s: %w", sourceTable, err)
+	}
+
+	targetColumns, err := target.GetColumns(QuoteIdentifier(targetTable))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get columns of %s: %w", targetTable, err)
+	}
+
+	diff := &DataDiff{binary: map[string]bool{}}
+
+	targetNames := map[string]bool{}
+	for _, column := range targetColumns {
+		targetNames[column.Name] = true
+	}
+
+	for _, column := range sourceColumns {
+		if !targetNames[column.Name] {
+			diff.Ignored = append(diff.Ignored, column.Name)
+			continue
+		}
+
+		diff.Columns = append(diff.Columns, column.Name)
+		diff.binary[column.Name] = isBinaryColumn(column.DataType)
+		delete(targetNames, column.Name)
+
+		if column.Key == "PRI" {
+			diff.PrimaryKey = append(diff.PrimaryKey, column.Name)
+		}
+	}
+
+	for _, column := range targetColumns {
+		if targetNames[column.Name] {
+			diff.Ignored = append(diff.Ignored, column.Name)
+		}
+	}
+
+	if len(diff.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("%s has no primary key to match the rows", sourceTable)
+	}
+
+	sourceRows, err := source.getDiffRows(sourceTable, diff.Columns, diff.PrimaryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	targetRows, err := target.getDiffRows(targetTable, diff.Columns, diff.PrimaryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	targetByKey := map[string]map[string]any{}
+	for _, row := range targetRows {
+		targetByKey[diff.rowKey(row)] = row
+	}
+
+	for _, sourceRow := range sourceRows {
+		key := diff.rowKey(sourceRow)
+
+		targetRow, ok := targetByKey[key]
+		if !ok {
+			diff.Rows = append(diff.Rows, RowDiff{Status: RowAdded, Source: sourceRow})
+			continue
+		}
+
+		delete(targetByKey, key)
+
+		var changed []string
+		for _, column := range diff.Columns {
+			if !sameValue(sourceRow[column], targetRow[column]) {
+				changed = append(changed, column)
+			}
+		}
+
+		if len(changed) == 0 {
+			diff.Same++
+			continue
+		}
+
+		diff.Rows = append(diff.Rows, RowDiff{
+			Status:  RowChanged,
+			Source:  sourceRow,
+			Target:  targetRow,
+			Changed: changed,
+		})
+	}
+
+	// keep the order of the target table for the removed rows
+	for _, targetRow := range targetRows {
+		if _, ok := targetByKey[diff.rowKey(targetRow)]; ok {
+			diff.Rows = append(diff.Rows, RowDiff{Status: RowRemoved, Target: targetRow})
+		}
+	}
+
+	return diff, nil
+}
+
+// getDiffRows returns the rows of the table ordered by the primary key
+func (client *DBClient) getDiffRows(table string, columns []string, primaryKey []string) ([]map[string]any, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s LIMIT %d",
+		quoteIdentifiers(columns),
+		QuoteIdentifier(table),
+		quoteIdentifiers(primaryKey),
+		maxDiffRows+1,
+	)
+
+	result, err := client.RunQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get rows of %s: %w", table, err)
+	}
+
+	if len(result.Rows) > maxDiffRows {
+		return nil, fmt.Errorf("%s has more than %d rows, it's too large to compare", table, maxDiffRows)
+	}
+
+	rows := make([]map[string]any, 0, len(result.Rows))
+
+	for _, values := range result.Rows {
+		row := map[string]any{}
+		for i, column := range result.Columns {
+			row[column] = values[i]
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func (d *DataDiff) rowKey(row map[string]any) string {
+	values := make([]string, len(d.PrimaryKey))
+	for i, column := range d.PrimaryKey {
+		values[i] = fmt.Sprintf("%v", row[column])
+	}
+
+	return strings.Join(values, "\x00")
+}
+
+func sameValue(a any, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
+
+// SyncSQL returns the statements that make the target table match the
+// source table: inserts for added rows, deletes for removed rows and
+// updates of the changed columns
+func (d *DataDiff) SyncSQL(targetTable string) []string {
+	var statements []string
+
+	table := QuoteIdentifier(targetTable)
+
+	for _, row := range d.Rows {
+		switch row.Status {
+		case RowAdded:
+			values := make([]string, len(d.Columns))
+			for i, column := range d.Columns {
+				values[i] = d.valueSQL(column, row.Source[column])
+			}
+
+			statements = append(statements, fmt.Sprintf(
+				"INSERT INTO %s (%s) VALUES (%s)",
+				table,
+				quoteIdentifiers(d.Columns),
+				strings.Join(values, ", "),
+			))
+		case RowRemoved:
+			statements = append(statements, fmt.Sprintf(
+				"DELETE FROM %s WHERE %s",
+				table,
+				d.whereKey(row.Target),
+			))
+		case RowChanged:
+			assignments := make([]string, len(row.Changed))
+			for i, column := range row.Changed {
+				assignments[i] = fmt.Sprintf("%s = %s", QuoteIdentifier(column), d.valueSQL(column, row.Source[column]))
+			}
+
+			statements = append(statements, fmt.Sprintf(
+				"UPDATE %s SET %s WHERE %s",
+				table,
+				strings.Join(assignments, ", "),
+				d.whereKey(row.Target),
+			))
+		}
+	}
+
+	return statements
+}
+
+func (d *DataDiff) whereKey(row map[string]any) string {
+	conditions := make([]string, len(d.PrimaryKey))
+	for i, column := range d.PrimaryKey {
+		conditions[i] = fmt.Sprintf("%s = %s", QuoteIdentifier(column), d.valueSQL(column, row[column]))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+// valueSQL renders a value from RunQuery as a literal, in hex for binary
+// columns
+func (d *DataDiff) valueSQL(column string, value any) string {
+	return literalSQL(value, d.binary[column])
+}
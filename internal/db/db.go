@@ -1,29 +1,86 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
+// ErrReadOnly is returned for writes on a read-only connection
+var ErrReadOnly = errors.New("Connection is read-only")
+
 type DBClient struct {
-	db *sql.DB
+	db       *sql.DB
+	readOnly bool
+}
+
+type ObjectType string
+
+const (
+	ObjectTable     ObjectType = "table"
+	ObjectView      ObjectType = "view"
+	ObjectProcedure ObjectType = "procedure"
+	ObjectFunction  ObjectType = "function"
+	ObjectTrigger   ObjectType = "trigger"
+	ObjectEvent     ObjectType = "event"
+)
+
+// DBObject is a named object in the database, e.g. a table or a trigger
+type DBObject struct {
+	Name string
+	Type ObjectType
 }
 
 type Column struct {
-	Name     string
-	DataType string
-	Null     bool
-	Key      string
-	Default  sql.NullString
-	Extra    string
+	Name      string
+	DataType  string
+	Collation sql.NullString
+	Null      bool
+	Key       string
+	Default   sql.NullString
+	Extra     string
+	Comment   string
 }
 
-func NewDBClient(connection string) (*DBClient, error) {
-	db, err := sql.Open("mysql", connection)
+// TableInfo is the metadata of a table from information_schema.TABLES.
+// Rows is an estimate for InnoDB tables.
+type TableInfo struct {
+	Name          string
+	Engine        sql.NullString
+	Rows          sql.NullInt64
+	DataLength    sql.NullInt64
+	IndexLength   sql.NullInt64
+	AutoIncrement sql.NullInt64
+	Collation     sql.NullString
+	CreateTime    sql.NullString
+	UpdateTime    sql.NullString
+	Comment       sql.NullString
+}
+
+// Size is the size of the data and the indexes in bytes
+func (t TableInfo) Size() int64 {
+	return t.DataLength.Int64 + t.IndexLength.Int64
+}
+
+// QueryResult is the result of an arbitrary SQL statement. Statements that
+// don't return rows (INSERT, UPDATE, ...) only have RowsAffected set.
+type QueryResult struct {
+	Columns      []string
+	Rows         [][]interface{}
+	RowsAffected int64
+}
+
+// NewDBClient opens a connection pool. Read-only clients refuse writes and
+// also put every session in read-only mode so the server rejects them too.
+func NewDBClient(connection string, readOnly bool) (*DBClient, error) {
+	db, err := openDB(connection, readOnly)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to connect to database: %w", err)
 	}
@@ -36,7 +93,126 @@ func NewDBClient(connection string) (*DBClient, error) {
 	db.SetMaxOpenConns(10)
 	db.SetMaxIdleConns(10)
 
-	return &DBClient{db}, nil
+	return &DBClient{db: db, readOnly: readOnly}, nil
+}
+
+func openDB(connection string, readOnly bool) (*sql.DB, error) {
+	if !readOnly {
+		return sql.Open("mysql", connection)
+	}
+
+	cfg, err := mysql.ParseDSN(connection)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.OpenDB(readOnlyConnector{connector}), nil
+}
+
+// readOnlyConnector runs SET SESSION TRANSACTION READ ONLY on every new
+// connection of the pool
+type readOnlyConnector struct {
+	driver.Connector
+}
+
+func (c readOnlyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("Driver does not support read-only sessions")
+	}
+
+	if _, err := execer.ExecContext(ctx, "SET SESSION TRANSACTION READ ONLY", nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Failed to make session read-only: %w", err)
+	}
+
+	return conn, nil
+}
+
+// TestConnection connects to the database and returns the server version
+// and the round trip time of a ping on the established connection
+func TestConnection(connection string) (string, time.Duration, error) {
+	db, err := sql.Open("mysql", connection)
+	if err != nil {
+		return "", 0, fmt.Errorf("Failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the first ping opens the connection, the second one measures latency
+	if err := db.PingContext(ctx); err != nil {
+		return "", 0, fmt.Errorf("Failed to ping database: %w", err)
+	}
+
+	start := time.Now()
+	if err := db.PingContext(ctx); err != nil {
+		return "", 0, fmt.Errorf("Failed to ping database: %w", err)
+	}
+	latency := time.Since(start)
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return "", 0, fmt.Errorf("Failed to get server version: %w", err)
+	}
+
+	return version, latency, nil
+}
+
+func (client *DBClient) Close() error {
+	return client.db.Close()
+}
+
+// Stats returns the statistics of the connection pool
+func (client *DBClient) Stats() sql.DBStats {
+	return client.db.Stats()
+}
+
+func (client *DBClient) ReadOnly() bool {
+	return client.readOnly
+}
+
+func (client *DBClient) GetDatabases() ([]string, error) {
+	rows, err := client.db.Query("SHOW DATABASES")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get databases: %w", err)
+	}
+
+	defer rows.Close()
+
+	var databases []string
+
+	for rows.Next() {
+		var database string
+		if err := rows.Scan(&database); err != nil {
+			return nil, fmt.Errorf("Failed to scan database name: %w", err)
+		}
+		databases = append(databases, database)
+	}
+
+	return databases, nil
+}
+
+// GetCurrentDatabase returns the database the client is connected to
+func (client *DBClient) GetCurrentDatabase() (string, error) {
+	var database sql.NullString
+	if err := client.db.QueryRow("SELECT DATABASE()").Scan(&database); err != nil {
+		return "", fmt.Errorf("Failed to get current database: %w", err)
+	}
+
+	return database.String, nil
 }
 
 func (client *DBClient) GetTables() ([]string, error) {
@@ -60,11 +236,161 @@ func (client *DBClient) GetTables() ([]string, error) {
 	return tableNames, nil
 }
 
-func (client *DBClient) GetRecords(
-	table string,
-	where string,
-	orderBy string,
-) ([]map[string]interface{}, error) {
+// GetObjects returns the tables, views, routines, triggers and events of
+// the current database
+func (client *DBClient) GetObjects() ([]DBObject, error) {
+	var objects []DBObject
+
+	rows, err := client.db.Query("SHOW FULL TABLES")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get tables from database: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, tableType string
+		if err := rows.Scan(&name, &tableType); err != nil {
+			return nil, fmt.Errorf("Failed to scan table name: %w", err)
+		}
+
+		objectType := ObjectTable
+		if strings.Contains(tableType, "VIEW") {
+			objectType = ObjectView
+		}
+
+		objects = append(objects, DBObject{Name: name, Type: objectType})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to get tables from database: %w", err)
+	}
+
+	queries := []string{
+		`SELECT ROUTINE_NAME, LOWER(ROUTINE_TYPE) FROM information_schema.ROUTINES
+			WHERE ROUTINE_SCHEMA = DATABASE() ORDER BY ROUTINE_NAME`,
+		`SELECT TRIGGER_NAME, 'trigger' FROM information_schema.TRIGGERS
+			WHERE TRIGGER_SCHEMA = DATABASE() ORDER BY TRIGGER_NAME`,
+		`SELECT EVENT_NAME, 'event' FROM information_schema.EVENTS
+			WHERE EVENT_SCHEMA = DATABASE() ORDER BY EVENT_NAME`,
+	}
+
+	for _, query := range queries {
+		rows, err := client.db.Query(query)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get objects from database: %w", err)
+		}
+
+		for rows.Next() {
+			var name, objectType string
+			if err := rows.Scan(&name, &objectType); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("Failed to scan object name: %w", err)
+			}
+
+			objects = append(objects, DBObject{Name: name, Type: ObjectType(objectType)})
+		}
+
+		err = rows.Err()
+		rows.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get objects from database: %w", err)
+		}
+	}
+
+	return objects, nil
+}
+
+// GetTableInfo returns the metadata of the tables and views in the current
+// database by name
+func (client *DBClient) GetTableInfo() (map[string]TableInfo, error) {
+	rows, err := client.db.Query(`SELECT TABLE_NAME, ENGINE, TABLE_ROWS, DATA_LENGTH,
+		INDEX_LENGTH, AUTO_INCREMENT, TABLE_COLLATION, CREATE_TIME, UPDATE_TIME, TABLE_COMMENT
+		FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get table metadata: %w", err)
+	}
+
+	defer rows.Close()
+
+	tables := map[string]TableInfo{}
+
+	for rows.Next() {
+		var t TableInfo
+		if err := rows.Scan(
+			&t.Name,
+			&t.Engine,
+			&t.Rows,
+			&t.DataLength,
+			&t.IndexLength,
+			&t.AutoIncrement,
+			&t.Collation,
+			&t.CreateTime,
+			&t.UpdateTime,
+			&t.Comment,
+		); err != nil {
+			return nil, fmt.Errorf("Failed to scan table metadata: %w", err)
+		}
+
+		tables[t.Name] = t
+	}
+
+	return tables, rows.Err()
+}
+
+// GetDefinition returns the CREATE statement of the object
+// (SHOW CREATE VIEW, SHOW CREATE PROCEDURE, ...)
+func (client *DBClient) GetDefinition(object DBObject) (string, error) {
+	query := fmt.Sprintf(
+		"SHOW CREATE %s %s",
+		strings.ToUpper(string(object.Type)),
+		QuoteIdentifier(object.Name),
+	)
+
+	rows, err := client.db.Query(query)
+	if err != nil {
+		return "", err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	if !rows.Next() {
+		return "", fmt.Errorf("%s %s not found", object.Type, object.Name)
+	}
+
+	values := make([]interface{}, len(columns))
+	for i := range columns {
+		values[i] = new(sql.RawBytes)
+	}
+
+	if err := rows.Scan(values...); err != nil {
+		return "", err
+	}
+
+	// The position of the statement differs per object type, but the column
+	// is always called "Create <Type>" or "SQL Original Statement"
+	for i, column := range columns {
+		if strings.HasPrefix(column, "Create ") || column == "SQL Original Statement" {
+			return string(*values[i].(*sql.RawBytes)), nil
+		}
+	}
+
+	return "", fmt.Errorf("No definition found for %s %s", object.Type, object.Name)
+}
+
+// GetCreateTable returns the DDL of the table (SHOW CREATE TABLE)
+func (client *DBClient) GetCreateTable(table string) (string, error) {
+	return client.GetDefinition(DBObject{Name: table, Type: ObjectTable})
+}
+
+// RecordsQuery returns the SELECT statement used by GetRecords
+func RecordsQuery(table string, where string, orderBy string) string {
 	query := fmt.Sprintf("SELECT * FROM %s", table)
 
 	if where != "" {
@@ -75,7 +401,15 @@ func (client *DBClient) GetRecords(
 		query = fmt.Sprintf("%s ORDER BY %s", query, orderBy)
 	}
 
-	query = fmt.Sprintf("%s LIMIT 200", query)
+	return fmt.Sprintf("%s LIMIT 200", query)
+}
+
+func (client *DBClient) GetRecords(
+	table string,
+	where string,
+	orderBy string,
+) ([]map[string]interface{}, error) {
+	query := RecordsQuery(table, where, orderBy)
 
 	rows, err := client.db.Query(query)
 	if err != nil {
@@ -124,7 +458,8 @@ func (client *DBClient) GetRecords(
 
 // return columns with metadata, use Column struct
 func (client *DBClient) GetColumns(tableName string) ([]Column, error) {
-	rows, err := client.db.Query("DESCRIBE " + tableName)
+	// FULL adds the collation and the comment to the DESCRIBE columns
+	rows, err := client.db.Query("SHOW FULL COLUMNS FROM " + tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -136,23 +471,30 @@ func (client *DBClient) GetColumns(tableName string) ([]Column, error) {
 		var (
 			column     string
 			dataType   string
+			collation  sql.NullString
 			null       string
 			key        string
 			defaultVal sql.NullString
 			extra      string
+			privileges string
+			comment    string
 		)
 
-		if err := rows.Scan(&column, &dataType, &null, &key, &defaultVal, &extra); err != nil {
+		if err := rows.Scan(
+			&column, &dataType, &collation, &null, &key, &defaultVal, &extra, &privileges, &comment,
+		); err != nil {
 			return nil, err
 		}
 
 		columns = append(columns, Column{
-			Name:     column,
-			DataType: dataType,
-			Null:     null == "YES",
-			Key:      key,
-			Default:  defaultVal,
-			Extra:    extra,
+			Name:      column,
+			DataType:  dataType,
+			Collation: collation,
+			Null:      null == "YES",
+			Key:       key,
+			Default:   defaultVal,
+			Extra:     extra,
+			Comment:   comment,
 		})
 	}
 
@@ -209,6 +551,10 @@ func (client *DBClient) UpdateRecordById(
 	id string,
 	record map[string]interface{},
 ) error {
+	if client.readOnly {
+		return ErrReadOnly
+	}
+
 	query := fmt.Sprintf("UPDATE %s SET ", tableName)
 
 	for col, val := range record {
@@ -233,7 +579,22 @@ func (client *DBClient) UpdateRecordById(
 	return nil
 }
 
+// Exec runs a statement that doesn't return rows, e.g. ALTER TABLE
+func (client *DBClient) Exec(statement string) error {
+	if client.readOnly {
+		return ErrReadOnly
+	}
+
+	_, err := client.db.Exec(statement)
+
+	return err
+}
+
 func (client *DBClient) DeleteRecord(tableName string, where string) error {
+	if client.readOnly {
+		return ErrReadOnly
+	}
+
 	if where == "" {
 		return fmt.Errorf("WHERE clause is required")
 	}
@@ -247,3 +608,153 @@ func (client *DBClient) DeleteRecord(tableName string, where string) error {
 
 	return nil
 }
+
+// RunQuery runs an arbitrary SQL statement. Values in the returned rows are
+// either a string or nil for NULL.
+func (client *DBClient) RunQuery(query string) (*QueryResult, error) {
+	if client.readOnly && !IsReadOnlyStatement(query) {
+		return nil, fmt.Errorf("%w, only SELECT, SHOW, DESCRIBE and EXPLAIN are allowed", ErrReadOnly)
+	}
+
+	if !ReturnsRows(query) {
+		result, err := client.db.Exec(query)
+		if err != nil {
+			return nil, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		return &QueryResult{RowsAffected: rowsAffected}, nil
+	}
+
+	rows, err := client.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get columns: %w", err)
+	}
+
+	result := &QueryResult{Columns: columns}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+
+		for i := range columns {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, fmt.Errorf("Failed to scan row: %w", err)
+		}
+
+		row := make([]interface{}, len(columns))
+
+		for i, val := range values {
+			switch val.(type) {
+			case nil:
+				row[i] = nil
+			case []byte:
+				row[i] = string(val.([]byte))
+			default:
+				row[i] = fmt.Sprintf("%v", val)
+			}
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ReturnsRows reports whether the statement produces a result set
+func ReturnsRows(query string) bool {
+	switch firstKeyword(query) {
+	case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH", "VALUES", "TABLE":
+		return true
+	}
+
+	return false
+}
+
+var (
+	writeKeywords  = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|REPLACE)\b`)
+	explainAnalyze = regexp.MustCompile(`(?i)^\s*EXPLAIN\s+ANALYZE\b`)
+)
+
+// IsReadOnlyStatement reports whether the statement is safe to run on a
+// read-only connection. WITH and EXPLAIN ANALYZE can wrap (and execute)
+// writes, so they are rejected when they mention a write keyword.
+func IsReadOnlyStatement(query string) bool {
+	if !ReturnsRows(query) {
+		return false
+	}
+
+	switch firstKeyword(query) {
+	case "WITH":
+		return !writeKeywords.MatchString(query)
+	case "EXPLAIN":
+		if explainAnalyze.MatchString(query) {
+			return !writeKeywords.MatchString(query)
+		}
+	}
+
+	return true
+}
+
+// firstKeyword returns the upper-cased first word of the statement,
+// skipping leading whitespace, comments and parentheses
+func firstKeyword(query string) string {
+	query = strings.TrimSpace(query)
+
+	for {
+		switch {
+		case strings.HasPrefix(query, "--"), strings.HasPrefix(query, "#"):
+			end := strings.Index(query, "\n")
+			if end == -1 {
+				return ""
+			}
+			query = strings.TrimSpace(query[end+1:])
+		case strings.HasPrefix(query, "/*"):
+			end := strings.Index(query, "*/")
+			if end == -1 {
+				return ""
+			}
+			query = strings.TrimSpace(query[end+2:])
+		case strings.HasPrefix(query, "("):
+			query = strings.TrimSpace(query[1:])
+		default:
+			fields := strings.FieldsFunc(query, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+			})
+			if len(fields) == 0 {
+				return ""
+			}
+			return strings.ToUpper(fields[0])
+		}
+	}
+}
+
+// QuoteIdentifier quotes a table, column or other object name with backticks
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteString quotes a string literal, escaping quotes and backslashes
+func QuoteString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+
+	return "'" + replacer.Replace(value) + "'"
+}
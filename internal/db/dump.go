@@ -0,0 +1,296 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// dumpBatchRows is the number of rows per INSERT statement of a dump
+const dumpBatchRows = 100
+
+// DumpOptions are the tables of a dump and whether to write their
+// structure, their data or both
+type DumpOptions struct {
+	Tables    []string
+	Structure bool
+	Data      bool
+}
+
+// Dump writes the tables as a SQL script like mysqldump: DROP and CREATE
+// TABLE statements for the structure and batched INSERTs for the data.
+// onTable is called before each table, e.g. to show the progress.
+func (client *DBClient) Dump(w io.Writer, options DumpOptions, onTable func(table string)) error {
+	database, err := client.GetCurrentDatabase()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "-- lazydb dump of %s\n", database)
+	fmt.Fprintf(w, "-- %s\n\n", time.Now().Format(time.DateTime))
+	fmt.Fprint(w, "SET NAMES utf8mb4;\n")
+	fmt.Fprint(w, "SET FOREIGN_KEY_CHECKS = 0;\n\n")
+
+	for _, table := range options.Tables {
+		if onTable != nil {
+			onTable(table)
+		}
+
+		if options.Structure {
+			createTable, err := client.GetCreateTable(table)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(w, "-- Structure of %s\n\n", table)
+			fmt.Fprintf(w, "DROP TABLE IF EXISTS %s;\n", QuoteIdentifier(table))
+			fmt.Fprintf(w, "%s;\n\n", createTable)
+		}
+
+		if options.Data {
+			if err := client.dumpRows(w, table); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprint(w, "SET FOREIGN_KEY_CHECKS = 1;\n")
+
+	return err
+}
+
+// dumpRows writes the rows of the table as INSERT statements. The rows are
+// streamed, so large tables aren't loaded in memory.
+func (client *DBClient) dumpRows(w io.Writer, table string) error {
+	rows, err := client.db.Query("SELECT * FROM " + QuoteIdentifier(table))
+	if err != nil {
+		return fmt.Errorf("Failed to get rows of %s: %w", table, err)
+	}
+
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("Failed to get columns: %w", err)
+	}
+
+	columns := make([]string, len(columnTypes))
+	binary := make([]bool, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = columnType.Name()
+		binary[i] = isBinaryType(columnType.DatabaseTypeName())
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", QuoteIdentifier(table), quoteIdentifiers(columns))
+
+	values := make([]any, len(columns))
+	valuePtrs := make([]any, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	var batch []string
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		_, err := fmt.Fprintf(w, "%s%s;\n", insert, strings.Join(batch, ",\n"))
+		batch = batch[:0]
+
+		return err
+	}
+
+	fmt.Fprintf(w, "-- Data of %s\n\n", table)
+
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return fmt.Errorf("Failed to scan row: %w", err)
+		}
+
+		literals := make([]string, len(values))
+		for i, value := range values {
+			literals[i] = literalSQL(value, binary[i])
+		}
+
+		batch = append(batch, "("+strings.Join(literals, ", ")+")")
+
+		if len(batch) == dumpBatchRows {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w)
+
+	return err
+}
+
+// literalSQL renders a scanned value as a literal. Binary data isn't valid
+// text, so it is written in hex like mysqldump --hex-blob.
+func literalSQL(value any, binary bool) string {
+	switch value := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if binary {
+			return fmt.Sprintf("X'%x'", value)
+		}
+
+		return QuoteString(string(value))
+	case string:
+		if binary {
+			return fmt.Sprintf("X'%x'", value)
+		}
+
+		return QuoteString(value)
+	default:
+		return QuoteString(fmt.Sprintf("%v", value))
+	}
+}
+
+// isBinaryType reports whether a column of the type holds bytes instead of
+// text, e.g. BLOB but not TEXT
+func isBinaryType(databaseType string) bool {
+	switch databaseType {
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+		return true
+	}
+
+	return false
+}
+
+// isBinaryColumn is isBinaryType for the type of a column from SHOW
+// COLUMNS, e.g. varbinary(16) or point
+func isBinaryColumn(dataType string) bool {
+	name, _, _ := strings.Cut(strings.ToUpper(dataType), "(")
+
+	switch name {
+	case "POINT", "LINESTRING", "POLYGON", "MULTIPOINT", "MULTILINESTRING", "MULTIPOLYGON", "GEOMETRYCOLLECTION":
+		return true
+	}
+
+	return isBinaryType(name)
+}
+
+// SplitStatements splits a SQL script into statements at the semicolons
+// outside of strings, quoted identifiers and comments. Comments are kept
+// with the statement that follows them, empty statements are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	var quote rune
+
+	start := 0
+	runes := []rune(script)
+
+	add := func(end int) {
+		statement := strings.TrimSpace(string(runes[start:end]))
+		if firstKeyword(statement) != "" {
+			statements = append(statements, statement)
+		}
+		start = end + 1
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			switch {
+			case r == '\\' && quote != '`':
+				i++
+			case r == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '#', r == '-' && isCommentDashes(runes[i:]):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/') {
+				i++
+			}
+			i++
+		case r == ';':
+			add(i)
+		}
+	}
+
+	add(len(runes))
+
+	return statements
+}
+
+// isCommentDashes reports whether the text starts with a -- comment, which
+// needs a space after the dashes in MySQL (1--1 is a subtraction)
+func isCommentDashes(text []rune) bool {
+	return len(text) >= 2 && text[1] == '-' && (len(text) == 2 || unicode.IsSpace(text[2]))
+}
+
+// ExecScript runs the statements in order on a single connection, so
+// session settings like FOREIGN_KEY_CHECKS apply to the statements after
+// them. The script starts in the database, a USE in the script switches to
+// another one. The connection is closed afterwards. onStatement is called
+// after each statement with its error and stops the script when it returns
+// false.
+func (client *DBClient) ExecScript(
+	ctx context.Context,
+	database string,
+	statements []string,
+	onStatement func(i int, err error) bool,
+) error {
+	if client.readOnly {
+		return ErrReadOnly
+	}
+
+	conn, err := client.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get a connection: %w", err)
+	}
+
+	defer conn.Close()
+
+	// the script can leave session settings behind, e.g. FOREIGN_KEY_CHECKS
+	// = 0 when it stops early or another database after USE, so the
+	// connection is closed instead of going back to the pool
+	defer conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+
+	if _, err := conn.ExecContext(ctx, "USE "+QuoteIdentifier(database)); err != nil {
+		return fmt.Errorf("Failed to use database %s: %w", database, err)
+	}
+
+	for i, statement := range statements {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		_, err := conn.ExecContext(ctx, statement)
+		if !onStatement(i, err) {
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PlanNode is a step of a query plan, e.g. a join or the access to a table.
+// Table steps have Table set, other steps only have a Label.
+type PlanNode struct {
+	Label     string
+	Table     string
+	Access    string
+	Key       string
+	Rows      string
+	Filtered  string
+	Condition string
+	Extra     []string
+	Children  []*PlanNode
+}
+
+// FullScan reports whether the step reads every row of the table
+func (n *PlanNode) FullScan() bool {
+	return n.Access == "ALL"
+}
+
+// FullScans counts the full table scans of the plan
+func (n *PlanNode) FullScans() int {
+	count := 0
+	if n.FullScan() {
+		count++
+	}
+
+	for _, child := range n.Children {
+		count += child.FullScans()
+	}
+
+	return count
+}
+
+// Explain returns the plan of the query. It uses EXPLAIN FORMAT=JSON and
+// falls back to the tabular EXPLAIN on servers that don't support it.
+func (client *DBClient) Explain(query string) (*PlanNode, error) {
+	var document string
+
+	err := client.db.QueryRow("EXPLAIN FORMAT=JSON " + query).Scan(&document)
+	if err == nil {
+		var plan map[string]any
+		if err := json.Unmarshal([]byte(document), &plan); err == nil {
+			return planFromJSON("query", plan), nil
+		}
+	}
+
+	result, err := client.RunQuery("EXPLAIN " + query)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to explain query: %w", err)
+	}
+
+	return planFromRows(result), nil
+}
+
+// ExplainAnalyze runs the query with EXPLAIN ANALYZE (MySQL 8.0.18+) and
+// returns the plan with the actual timings and row counts as text. Since the
+// query is executed, only read-only statements are allowed.
+func (client *DBClient) ExplainAnalyze(query string) (string, error) {
+	if !IsReadOnlyStatement(query) {
+		return "", fmt.Errorf("EXPLAIN ANALYZE runs the statement, only read-only statements can be analyzed")
+	}
+
+	result, err := client.RunQuery("EXPLAIN ANALYZE " + query)
+	if err != nil {
+		return "", fmt.Errorf("Failed to analyze query: %w", err)
+	}
+
+	var lines []string
+	for _, row := range result.Rows {
+		for _, value := range row {
+			if value != nil {
+				lines = append(lines, value.(string))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// planFromJSON converts an object of the EXPLAIN FORMAT=JSON output, e.g.
+// a query_block, nested_loop or table, to a plan node
+func planFromJSON(label string, object map[string]any) *PlanNode {
+	node := &PlanNode{Label: label}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		switch value := object[key].(type) {
+		case map[string]any:
+			if key == "cost_info" {
+				if cost, ok := value["query_cost"]; ok {
+					node.Extra = append(node.Extra, fmt.Sprintf("cost %v", cost))
+				}
+				continue
+			}
+
+			node.Children = append(node.Children, planFromJSON(key, value))
+		case []any:
+			// e.g. "nested_loop": [{"table": {...}}, {"table": {...}}]
+			list := &PlanNode{Label: key}
+
+			for _, item := range value {
+				item, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				child := planFromJSON(key, item)
+				if child.Table == "" && len(child.Extra) == 0 {
+					list.Children = append(list.Children, child.Children...)
+				} else {
+					list.Children = append(list.Children, child)
+				}
+			}
+
+			if len(list.Children) > 0 {
+				node.Children = append(node.Children, list)
+			}
+		case bool:
+			if value && strings.HasPrefix(key, "using_") {
+				node.Extra = append(node.Extra, strings.ReplaceAll(key, "_", " "))
+			}
+		case string, float64:
+			text := fmt.Sprintf("%v", value)
+
+			switch key {
+			case "table_name":
+				node.Table = text
+			case "access_type":
+				node.Access = text
+			case "key":
+				node.Key = text
+			case "rows_examined_per_scan":
+				node.Rows = text
+			case "filtered":
+				node.Filtered = text
+			case "attached_condition":
+				node.Condition = text
+			case "message":
+				node.Extra = append(node.Extra, text)
+			}
+		}
+	}
+
+	// Single child wrappers like {"query_block": {...}} only add nesting
+	if node.Table == "" && len(node.Extra) == 0 && len(node.Children) == 1 && label == "query" {
+		return node.Children[0]
+	}
+
+	return node
+}
+
+// planFromRows converts the tabular EXPLAIN output to a flat plan
+func planFromRows(result *QueryResult) *PlanNode {
+	root := &PlanNode{Label: "query"}
+
+	// Servers with a text plan (one line per row) have no id column
+	textPlan := !slices.ContainsFunc(result.Columns, func(column string) bool {
+		return strings.EqualFold(column, "id")
+	})
+
+	for _, row := range result.Rows {
+		values := map[string]string{}
+		for i, column := range result.Columns {
+			if row[i] != nil {
+				values[strings.ToLower(column)] = row[i].(string)
+			}
+		}
+
+		label := strings.TrimSpace(fmt.Sprintf("%s %s", values["id"], values["select_type"]))
+
+		if textPlan && len(row) > 0 && row[0] != nil {
+			label = row[0].(string)
+		}
+
+		node := &PlanNode{
+			Label:    label,
+			Table:    values["table"],
+			Access:   values["type"],
+			Key:      values["key"],
+			Rows:     values["rows"],
+			Filtered: values["filtered"],
+		}
+
+		if extra := values["extra"]; extra != "" {
+			node.Extra = strings.Split(extra, "; ")
+		}
+
+		root.Children = append(root.Children, node)
+	}
+
+	return root
+}
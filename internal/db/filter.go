@@ -0,0 +1,468 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// FilterOperator compares a column with values in a WHERE condition
+type FilterOperator string
+
+const (
+	FilterEquals      FilterOperator = "="
+	FilterNotEquals   FilterOperator = "!="
+	FilterIsNull      FilterOperator = "IS NULL"
+	FilterIsNotNull   FilterOperator = "IS NOT NULL"
+	FilterContains    FilterOperator = "LIKE"
+	FilterGreaterThan FilterOperator = ">"
+	FilterAtLeast     FilterOperator = ">="
+	FilterLessThan    FilterOperator = "<"
+	FilterAtMost      FilterOperator = "<="
+	FilterIn          FilterOperator = "IN"
+)
+
+// FilterSQL returns the condition comparing the column with the values.
+// Values are strings or nil for NULL, as returned by GetRecords. IS NULL
+// and IS NOT NULL ignore the values and IN uses all of them, the other
+// operators use the first one.
+func FilterSQL(column string, operator FilterOperator, values []any) string {
+	name := QuoteIdentifier(column)
+
+	var value any
+	if len(values) > 0 {
+		value = values[0]
+	}
+
+	switch operator {
+	case FilterIsNull, FilterIsNotNull:
+		return fmt.Sprintf("%s %s", name, operator)
+	case FilterIn:
+		var literals []string
+		hasNull := false
+
+		for _, value := range values {
+			if value == nil {
+				hasNull = true
+				continue
+			}
+
+			literal := literalSQL(value, false)
+			if !slices.Contains(literals, literal) {
+				literals = append(literals, literal)
+			}
+		}
+
+		// IN (NULL) never matches
+		switch {
+		case len(literals) == 0:
+			return fmt.Sprintf("%s IS NULL", name)
+		case hasNull:
+			return fmt.Sprintf("(%s IN (%s) OR %s IS NULL)", name, strings.Join(literals, ", "), name)
+		}
+
+		return fmt.Sprintf("%s IN (%s)", name, strings.Join(literals, ", "))
+	case FilterContains:
+		text := ""
+		if value != nil {
+			text = fmt.Sprintf("%v", value)
+		}
+
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+
+		return fmt.Sprintf("%s LIKE %s", name, QuoteString("%"+escaped+"%"))
+	}
+
+	// = NULL never matches
+	if value == nil {
+		switch operator {
+		case FilterEquals:
+			return fmt.Sprintf("%s IS NULL", name)
+		case FilterNotEquals:
+			return fmt.Sprintf("%s IS NOT NULL", name)
+		}
+	}
+
+	return fmt.Sprintf("%s %s %s", name, operator, literalSQL(value, false))
+}
+
+// AndWhere adds the condition to a WHERE filter. The filter is put in
+// parentheses so the condition applies to all of it, whatever operators
+// it uses (OR, ||, XOR, ...).
+func AndWhere(where string, condition string) string {
+	where = strings.TrimSpace(where)
+	if where == "" {
+		return condition
+	}
+
+	return fmt.Sprintf("(%s) AND %s", where, condition)
+}
+
+// FilterCondition is a condition of a Filter. Value is the text to compare
+// with, a comma separated list for IN.
+type FilterCondition struct {
+	Column   string
+	Operator FilterOperator
+	Value    string
+}
+
+func (c FilterCondition) SQL() string {
+	var values []any
+
+	switch c.Operator {
+	case FilterIn:
+		for _, value := range strings.Split(c.Value, ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+	default:
+		values = []any{c.Value}
+	}
+
+	return FilterSQL(c.Column, c.Operator, values)
+}
+
+// Filter is a WHERE filter made of groups of conditions. The conditions of
+// a group are combined with AND and the groups with OR.
+type Filter struct {
+	Groups [][]FilterCondition
+}
+
+func (f Filter) SQL() string {
+	var groups []string
+
+	for _, group := range f.Groups {
+		if len(group) == 0 {
+			continue
+		}
+
+		conditions := make([]string, len(group))
+		for i, condition := range group {
+			conditions[i] = condition.SQL()
+		}
+
+		groups = append(groups, strings.Join(conditions, " AND "))
+	}
+
+	if len(groups) < 2 {
+		return strings.Join(groups, "")
+	}
+
+	for i, group := range groups {
+		if strings.Contains(group, " AND ") {
+			groups[i] = "(" + group + ")"
+		}
+	}
+
+	return strings.Join(groups, " OR ")
+}
+
+// FilterOperators returns the operators that make sense for the column,
+// e.g. no LIKE for numbers and IS NULL only for nullable columns
+func FilterOperators(column Column) []FilterOperator {
+	dataType := strings.ToLower(column.DataType)
+
+	var operators []FilterOperator
+
+	switch {
+	case strings.HasPrefix(dataType, "tinyint"),
+		strings.HasPrefix(dataType, "smallint"),
+		strings.HasPrefix(dataType, "mediumint"),
+		strings.HasPrefix(dataType, "int"),
+		strings.HasPrefix(dataType, "bigint"),
+		strings.HasPrefix(dataType, "decimal"),
+		strings.HasPrefix(dataType, "float"),
+		strings.HasPrefix(dataType, "double"),
+		strings.HasPrefix(dataType, "date"),
+		strings.HasPrefix(dataType, "time"),
+		strings.HasPrefix(dataType, "year"):
+		operators = []FilterOperator{
+			FilterEquals, FilterNotEquals,
+			FilterGreaterThan, FilterAtLeast, FilterLessThan, FilterAtMost,
+			FilterIn,
+		}
+	case isStringType(dataType):
+		operators = []FilterOperator{FilterEquals, FilterNotEquals, FilterContains, FilterIn}
+	default:
+		// json, blobs, spatial types
+		operators = []FilterOperator{FilterContains}
+	}
+
+	if column.Null {
+		operators = append(operators, FilterIsNull, FilterIsNotNull)
+	}
+
+	return operators
+}
+
+var (
+	identifierPattern = "(`(?:[^`]|``)+`|\\w+)"
+	literalPattern    = `('(?:[^'\\]|\\.|'')*'|-?\d+(?:\.\d+)?)`
+
+	compareCondition = regexp.MustCompile(`^` + identifierPattern + `\s*(=|!=|<>|>=|<=|>|<)\s*` + literalPattern + `$`)
+	nullCondition    = regexp.MustCompile(`(?i)^` + identifierPattern + `\s+IS\s+(NOT\s+)?NULL$`)
+	likeCondition    = regexp.MustCompile(`(?i)^` + identifierPattern + `\s+LIKE\s+` + literalPattern + `$`)
+	inCondition      = regexp.MustCompile(`(?i)^` + identifierPattern + `\s+IN\s*\((.*)\)$`)
+	literal          = regexp.MustCompile(literalPattern)
+	likeContains     = regexp.MustCompile(`^%((?:[^%_\\]|\\.)*)%$`)
+	likeEscape       = regexp.MustCompile(`\\(.)`)
+)
+
+// maxFilterGroups limits the groups of a parsed filter, since each nested
+// group multiplies them
+const maxFilterGroups = 32
+
+// ParseFilter reads a WHERE filter back into groups of conditions, e.g. to
+// edit a typed filter in the filter builder. It only understands the
+// filters that Filter.SQL and AndWhere return, and simple typed ones like
+// id = 5.
+func ParseFilter(where string) (Filter, bool) {
+	if strings.TrimSpace(where) == "" {
+		return Filter{}, true
+	}
+
+	groups, ok := parseGroups(where)
+	if !ok {
+		return Filter{}, false
+	}
+
+	return Filter{Groups: groups}, true
+}
+
+// parseGroups reads conditions combined with AND and OR. A nested group is
+// spread over the group around it, e.g. (a OR b) AND c becomes
+// (a AND c) OR (b AND c).
+func parseGroups(sql string) ([][]FilterCondition, bool) {
+	var groups [][]FilterCondition
+
+	for _, groupSQL := range splitTopLevel(stripParentheses(sql), "OR") {
+		// the groups made of the conditions so far
+		product := [][]FilterCondition{nil}
+
+		for _, conditionSQL := range splitTopLevel(stripParentheses(groupSQL), "AND") {
+			conditionSQL = stripParentheses(conditionSQL)
+
+			var alternatives [][]FilterCondition
+
+			if len(splitTopLevel(conditionSQL, "OR")) > 1 || len(splitTopLevel(conditionSQL, "AND")) > 1 {
+				nested, ok := parseGroups(conditionSQL)
+				if !ok {
+					return nil, false
+				}
+
+				alternatives = nested
+			} else {
+				condition, ok := parseCondition(conditionSQL)
+				if !ok {
+					return nil, false
+				}
+
+				alternatives = [][]FilterCondition{{condition}}
+			}
+
+			var next [][]FilterCondition
+			for _, group := range product {
+				for _, alternative := range alternatives {
+					next = append(next, append(slices.Clone(group), alternative...))
+				}
+			}
+
+			if len(groups)+len(next) > maxFilterGroups {
+				return nil, false
+			}
+
+			product = next
+		}
+
+		groups = append(groups, product...)
+	}
+
+	return groups, true
+}
+
+func parseCondition(sql string) (FilterCondition, bool) {
+	if match := nullCondition.FindStringSubmatch(sql); match != nil {
+		operator := FilterIsNull
+		if match[2] != "" {
+			operator = FilterIsNotNull
+		}
+
+		return FilterCondition{Column: unquoteIdentifier(match[1]), Operator: operator}, true
+	}
+
+	if match := compareCondition.FindStringSubmatch(sql); match != nil {
+		operator := FilterOperator(match[2])
+		if operator == "<>" {
+			operator = FilterNotEquals
+		}
+
+		return FilterCondition{
+			Column:   unquoteIdentifier(match[1]),
+			Operator: operator,
+			Value:    unquoteLiteral(match[3]),
+		}, true
+	}
+
+	if match := likeCondition.FindStringSubmatch(sql); match != nil {
+		// only contains, other patterns can't be shown as a value
+		pattern := likeContains.FindStringSubmatch(unquoteLiteral(match[2]))
+		if pattern == nil {
+			return FilterCondition{}, false
+		}
+
+		return FilterCondition{
+			Column:   unquoteIdentifier(match[1]),
+			Operator: FilterContains,
+			Value:    likeEscape.ReplaceAllString(pattern[1], "$1"),
+		}, true
+	}
+
+	if match := inCondition.FindStringSubmatch(sql); match != nil {
+		literals := literal.FindAllString(match[2], -1)
+
+		// anything but literals and commas, e.g. a subquery
+		if strings.Trim(literal.ReplaceAllString(match[2], ""), ", ") != "" {
+			return FilterCondition{}, false
+		}
+
+		values := make([]string, len(literals))
+		for i, value := range literals {
+			values[i] = unquoteLiteral(value)
+
+			// the value is a comma separated list
+			if strings.Contains(values[i], ",") {
+				return FilterCondition{}, false
+			}
+		}
+
+		return FilterCondition{
+			Column:   unquoteIdentifier(match[1]),
+			Operator: FilterIn,
+			Value:    strings.Join(values, ", "),
+		}, true
+	}
+
+	return FilterCondition{}, false
+}
+
+// splitTopLevel splits the SQL at the keyword outside of strings, quoted
+// identifiers and parentheses
+func splitTopLevel(sql string, keyword string) []string {
+	var parts []string
+	var quote byte
+
+	depth := 0
+	start := 0
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		if quote != 0 {
+			switch {
+			case c == '\\' && quote != '`':
+				i++
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && isKeywordAt(sql, i, keyword):
+			parts = append(parts, strings.TrimSpace(sql[start:i]))
+			i += len(keyword)
+			start = i
+		}
+	}
+
+	return append(parts, strings.TrimSpace(sql[start:]))
+}
+
+// isKeywordAt reports whether the keyword is at position i of the SQL as a
+// separate word
+func isKeywordAt(sql string, i int, keyword string) bool {
+	end := i + len(keyword)
+
+	return end < len(sql) &&
+		i > 0 &&
+		strings.EqualFold(sql[i:end], keyword) &&
+		unicode.IsSpace(rune(sql[i-1])) &&
+		unicode.IsSpace(rune(sql[end]))
+}
+
+// stripParentheses removes the parentheses around the whole SQL
+func stripParentheses(sql string) string {
+	sql = strings.TrimSpace(sql)
+
+	for strings.HasPrefix(sql, "(") && closesAtEnd(sql) {
+		sql = strings.TrimSpace(sql[1 : len(sql)-1])
+	}
+
+	return sql
+}
+
+// closesAtEnd reports whether the parenthesis at the start of the SQL is
+// closed at its end, unlike in (a) OR (b)
+func closesAtEnd(sql string) bool {
+	var quote byte
+	depth := 0
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		if quote != 0 {
+			switch {
+			case c == '\\' && quote != '`':
+				i++
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i == len(sql)-1
+			}
+		}
+	}
+
+	return false
+}
+
+// unquoteIdentifier reverses QuoteIdentifier, names without backticks are
+// returned as they are
+func unquoteIdentifier(name string) string {
+	if len(name) < 2 || name[0] != '`' {
+		return name
+	}
+
+	return strings.ReplaceAll(name[1:len(name)-1], "``", "`")
+}
+
+// unquoteLiteral reverses QuoteString, numbers are returned as they are
+func unquoteLiteral(value string) string {
+	if len(value) < 2 || value[0] != '\'' {
+		return value
+	}
+
+	replacer := strings.NewReplacer(
+		`\\`, `\`, `\'`, `'`, `''`, `'`, `\"`, `"`,
+		`\0`, "\x00", `\n`, "\n", `\r`, "\r", `\t`, "\t", `\Z`, "\x1a",
+	)
+
+	return replacer.Replace(value[1 : len(value)-1])
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFilterBuilderRoundTrip(t *testing.T) {
+	filters := []Filter{
+		{},
+		{Groups: [][]FilterCondition{{{Column: "id", Operator: FilterEquals, Value: "5"}}}},
+		{Groups: [][]FilterCondition{{{Column: "name", Operator: FilterEquals, Value: ""}}}},
+		{Groups: [][]FilterCondition{{{Column: "name", Operator: FilterNotEquals, Value: "it's"}}}},
+		{Groups: [][]FilterCondition{{{Column: "age", Operator: FilterGreaterThan, Value: "18"}}}},
+		{Groups: [][]FilterCondition{{{Column: "age", Operator: FilterAtLeast, Value: "18"}}}},
+		{Groups: [][]FilterCondition{{{Column: "age", Operator: FilterLessThan, Value: "65"}}}},
+		{Groups: [][]FilterCondition{{{Column: "age", Operator: FilterAtMost, Value: "65"}}}},
+		{Groups: [][]FilterCondition{{{Column: "name", Operator: FilterContains, Value: `50%_off \ it's`}}}},
+		{Groups: [][]FilterCondition{{{Column: "id", Operator: FilterIn, Value: "1, 2, 3"}}}},
+		{Groups: [][]FilterCondition{{{Column: "deleted_at", Operator: FilterIsNull}}}},
+		{Groups: [][]FilterCondition{{{Column: "deleted_at", Operator: FilterIsNotNull}}}},
+		{Groups: [][]FilterCondition{{{Column: "order", Operator: FilterEquals, Value: "and or"}}}},
+		{Groups: [][]FilterCondition{
+			{
+				{Column: "status", Operator: FilterEquals, Value: "active"},
+				{Column: "name", Operator: FilterContains, Value: "(a OR b)"},
+			},
+			{
+				{Column: "deleted_at", Operator: FilterIsNull},
+			},
+			{
+				{Column: "id", Operator: FilterIn, Value: "7, 8"},
+				{Column: "age", Operator: FilterAtLeast, Value: "21"},
+			},
+		}},
+	}
+
+	for _, filter := range filters {
+		where := filter.SQL()
+
+		parsed, ok := ParseFilter(where)
+		if !ok {
+			t.Errorf("ParseFilter(%q) failed", where)
+			continue
+		}
+
+		if !reflect.DeepEqual(parsed, filter) {
+			t.Errorf("ParseFilter(%q) = %+v, want %+v", where, parsed, filter)
+		}
+
+		if parsed.SQL() != where {
+			t.Errorf("ParseFilter(%q).SQL() = %q", where, parsed.SQL())
+		}
+	}
+}
+
+func TestQuickFilterRoundTrip(t *testing.T) {
+	tests := []struct {
+		where      string
+		conditions []string
+		want       [][]FilterCondition
+	}{
+		{
+			conditions: []string{
+				FilterSQL("status", FilterEquals, []any{"active"}),
+				FilterSQL("deleted_at", FilterEquals, []any{nil}),
+				FilterSQL("name", FilterContains, []any{"50%"}),
+				FilterSQL("age", FilterGreaterThan, []any{"18"}),
+			},
+			want: [][]FilterCondition{{
+				{Column: "status", Operator: FilterEquals, Value: "active"},
+				{Column: "deleted_at", Operator: FilterIsNull},
+				{Column: "name", Operator: FilterContains, Value: "50%"},
+				{Column: "age", Operator: FilterGreaterThan, Value: "18"},
+			}},
+		},
+		{
+			// IN with NULL is (a IN (...) OR a IS NULL)
+			conditions: []string{
+				FilterSQL("id", FilterIn, []any{"1", nil, "2", "1"}),
+				FilterSQL("age", FilterLessThan, []any{"65"}),
+			},
+			want: [][]FilterCondition{
+				{
+					{Column: "id", Operator: FilterIn, Value: "1, 2"},
+					{Column: "age", Operator: FilterLessThan, Value: "65"},
+				},
+				{
+					{Column: "id", Operator: FilterIsNull},
+					{Column: "age", Operator: FilterLessThan, Value: "65"},
+				},
+			},
+		},
+		{
+			where:      "`a` = '1' OR `b` = '2'",
+			conditions: []string{FilterSQL("c", FilterNotEquals, []any{nil})},
+			want: [][]FilterCondition{
+				{
+					{Column: "a", Operator: FilterEquals, Value: "1"},
+					{Column: "c", Operator: FilterIsNotNull},
+				},
+				{
+					{Column: "b", Operator: FilterEquals, Value: "2"},
+					{Column: "c", Operator: FilterIsNotNull},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		where := test.where
+		for _, condition := range test.conditions {
+			where = AndWhere(where, condition)
+		}
+
+		parsed, ok := ParseFilter(where)
+		if !ok {
+			t.Errorf("ParseFilter(%q) failed", where)
+			continue
+		}
+
+		if !reflect.DeepEqual(parsed.Groups, test.want) {
+			t.Errorf("ParseFilter(%q) = %+v, want %+v", where, parsed.Groups, test.want)
+		}
+
+		// applying the builder keeps the rows of the quick filters
+		again, ok := ParseFilter(parsed.SQL())
+		if !ok || !reflect.DeepEqual(again, parsed) {
+			t.Errorf("ParseFilter(%q) = %+v, want %+v", parsed.SQL(), again, parsed)
+		}
+	}
+}
+
+func TestAndWhere(t *testing.T) {
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{"", "`c` = '3'"},
+		{"  ", "`c` = '3'"},
+		{"`a` = 1", "(`a` = 1) AND `c` = '3'"},
+		{"`a` = 1 OR `b` = 2", "(`a` = 1 OR `b` = 2) AND `c` = '3'"},
+		{"`a` = 1 || `b` = 2", "(`a` = 1 || `b` = 2) AND `c` = '3'"},
+		{"`a` = 1 XOR `b` = 2", "(`a` = 1 XOR `b` = 2) AND `c` = '3'"},
+	}
+
+	for _, test := range tests {
+		if got := AndWhere(test.where, "`c` = '3'"); got != test.want {
+			t.Errorf("AndWhere(%q) = %q, want %q", test.where, got, test.want)
+		}
+	}
+}
+
+func TestParseFilterUnsupported(t *testing.T) {
+	for _, where := range []string{
+		"`id` IN (SELECT `id` FROM `users`)",
+		"`name` LIKE 'a%'",
+		"`a` = 1 || `b` = 2",
+		"`a` = 1 XOR `b` = 2",
+		"NOT `a` = 1",
+		"`a` = `b`",
+	} {
+		if filter, ok := ParseFilter(where); ok {
+			t.Errorf("ParseFilter(%q) = %+v, want failure", where, filter)
+		}
+	}
+}
+
+func TestFilterOperators(t *testing.T) {
+	numeric := []string{"int", "int(11) unsigned", "tinyint(1)", "smallint", "mediumint", "bigint", "decimal(10,2)", "datetime"}
+	for _, dataType := range numeric {
+		if !slices.Contains(FilterOperators(Column{DataType: dataType}), FilterGreaterThan) {
+			t.Errorf("FilterOperators(%s) has no >", dataType)
+		}
+	}
+
+	for _, dataType := range []string{"point", "multipoint", "geometry", "blob", "json"} {
+		if operators := FilterOperators(Column{DataType: dataType}); !slices.Equal(operators, []FilterOperator{FilterContains}) {
+			t.Errorf("FilterOperators(%s) = %v, want only LIKE", dataType, operators)
+		}
+	}
+
+	if !slices.Contains(FilterOperators(Column{DataType: "varchar(50)"}), FilterContains) {
+		t.Errorf("FilterOperators(varchar) has no LIKE")
+	}
+}
@@ -0,0 +1,289 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ColumnDefinition describes a column for CREATE TABLE and ALTER TABLE
+// statements. Default is the value as typed by the user, see defaultSQL.
+// Collation and Comment are left out of the statement when empty, and the
+// collation also for types other than strings.
+type ColumnDefinition struct {
+	Name          string
+	Type          string
+	Collation     string
+	Nullable      bool
+	Default       string
+	AutoIncrement bool
+	Extra         string
+	Comment       string
+}
+
+// IndexDefinition describes an index for CREATE TABLE and ALTER TABLE
+// statements. Lengths are the prefix lengths of the columns, 0 (or left
+// out) for the whole column. Type is FULLTEXT or SPATIAL, empty for other
+// indexes.
+type IndexDefinition struct {
+	Name    string
+	Columns []string
+	Lengths []int
+	Unique  bool
+	Type    string
+}
+
+// Definition converts a column to a definition, e.g. to prefill a form that
+// modifies the column. The collation and the comment are kept, since
+// MODIFY COLUMN resets them when they are left out.
+func (c Column) Definition() ColumnDefinition {
+	definition := ColumnDefinition{
+		Name:      c.Name,
+		Type:      c.DataType,
+		Collation: c.Collation.String,
+		Nullable:  c.Null,
+		Comment:   c.Comment,
+	}
+
+	if c.Default.Valid {
+		definition.Default = c.Default.String
+
+		// make the empty string distinguishable from "no default"
+		if definition.Default == "" {
+			definition.Default = "''"
+		}
+	}
+
+	var extra []string
+	for _, word := range strings.Fields(c.Extra) {
+		switch strings.ToLower(word) {
+		case "auto_increment":
+			definition.AutoIncrement = true
+		case "default_generated":
+		default:
+			extra = append(extra, word)
+		}
+	}
+	definition.Extra = strings.Join(extra, " ")
+
+	return definition
+}
+
+// SQL renders the column definition, e.g. `name` varchar(50) NOT NULL DEFAULT 'none'
+func (c ColumnDefinition) SQL() string {
+	parts := []string{QuoteIdentifier(c.Name), c.Type}
+
+	// the collation implies the charset, and is rejected when the type was
+	// changed to one without a charset
+	if c.Collation != "" && isStringType(c.Type) {
+		parts = append(parts, "COLLATE "+c.Collation)
+	}
+
+	if c.Nullable {
+		parts = append(parts, "NULL")
+	} else {
+		parts = append(parts, "NOT NULL")
+	}
+
+	if c.Default != "" {
+		parts = append(parts, "DEFAULT "+defaultSQL(c.Default))
+	}
+
+	if c.AutoIncrement {
+		parts = append(parts, "AUTO_INCREMENT")
+	}
+
+	if c.Extra != "" {
+		parts = append(parts, c.Extra)
+	}
+
+	if c.Comment != "" {
+		parts = append(parts, "COMMENT "+QuoteString(c.Comment))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// SQL renders the index definition for CREATE TABLE, e.g. UNIQUE KEY `name` (`a`, `b`)
+func (i IndexDefinition) SQL() string {
+	return fmt.Sprintf("%s %s (%s)", i.keyword("KEY"), QuoteIdentifier(i.Name), i.columnsSQL())
+}
+
+// keyword puts UNIQUE, FULLTEXT or SPATIAL in front of KEY or INDEX
+func (i IndexDefinition) keyword(word string) string {
+	switch {
+	case i.Type != "":
+		return i.Type + " " + word
+	case i.Unique:
+		return "UNIQUE " + word
+	}
+
+	return word
+}
+
+// columnsSQL renders the columns with their prefix lengths, e.g. `a`, `b`(10)
+func (i IndexDefinition) columnsSQL() string {
+	columns := make([]string, len(i.Columns))
+	for j, column := range i.Columns {
+		columns[j] = QuoteIdentifier(column)
+
+		if j < len(i.Lengths) && i.Lengths[j] > 0 {
+			columns[j] += fmt.Sprintf("(%d)", i.Lengths[j])
+		}
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// isStringType reports whether the type has a charset and a collation
+func isStringType(dataType string) bool {
+	dataType = strings.ToLower(dataType)
+
+	return strings.HasPrefix(dataType, "char") ||
+		strings.HasPrefix(dataType, "varchar") ||
+		strings.HasSuffix(dataType, "text") ||
+		strings.HasPrefix(dataType, "enum") ||
+		strings.HasPrefix(dataType, "set")
+}
+
+var rawDefault = regexp.MustCompile(
+	`(?i)^(NULL|TRUE|FALSE|CURRENT_TIMESTAMP(\(\d*\))?|NOW\(\d*\)|-?\d+(\.\d+)?|'.*'|\(.*\))$`,
+)
+
+// defaultSQL returns the default value as SQL. Keywords, numbers, quoted
+// strings and (expressions) are used as is, anything else is quoted.
+func defaultSQL(value string) string {
+	if rawDefault.MatchString(value) {
+		return value
+	}
+
+	return QuoteString(value)
+}
+
+func AddColumnSQL(table string, column ColumnDefinition, after string) string {
+	statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", QuoteIdentifier(table), column.SQL())
+
+	if after != "" {
+		statement = fmt.Sprintf("%s AFTER %s", statement, QuoteIdentifier(after))
+	}
+
+	return statement
+}
+
+func ModifyColumnSQL(table string, column ColumnDefinition) string {
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", QuoteIdentifier(table), column.SQL())
+}
+
+func RenameColumnSQL(table string, oldName string, newName string) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s RENAME COLUMN %s TO %s",
+		QuoteIdentifier(table),
+		QuoteIdentifier(oldName),
+		QuoteIdentifier(newName),
+	)
+}
+
+func DropColumnSQL(table string, column string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", QuoteIdentifier(table), QuoteIdentifier(column))
+}
+
+func AddIndexSQL(table string, index IndexDefinition) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD %s %s (%s)",
+		QuoteIdentifier(table),
+		index.keyword("INDEX"),
+		QuoteIdentifier(index.Name),
+		index.columnsSQL(),
+	)
+}
+
+func DropIndexSQL(table string, index string) string {
+	if index == "PRIMARY" {
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", QuoteIdentifier(table))
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", QuoteIdentifier(table), QuoteIdentifier(index))
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = QuoteIdentifier(name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// TableDefinition describes a new table for CREATE TABLE. Engine, Charset
+// and Collation are left out of the statement when empty.
+type TableDefinition struct {
+	Name       string
+	Columns    []ColumnDefinition
+	PrimaryKey []string
+	Indexes    []IndexDefinition
+	Engine     string
+	Charset    string
+	Collation  string
+}
+
+func CreateTableSQL(table TableDefinition) string {
+	var lines []string
+
+	for _, column := range table.Columns {
+		lines = append(lines, "  "+column.SQL())
+	}
+
+	if len(table.PrimaryKey) > 0 {
+		lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", quoteIdentifiers(table.PrimaryKey)))
+	}
+
+	for _, index := range table.Indexes {
+		lines = append(lines, "  "+index.SQL())
+	}
+
+	statement := fmt.Sprintf("CREATE TABLE %s (\n%s\n)", QuoteIdentifier(table.Name), strings.Join(lines, ",\n"))
+
+	if table.Engine != "" {
+		statement += " ENGINE=" + table.Engine
+	}
+
+	if table.Charset != "" {
+		statement += " DEFAULT CHARSET=" + table.Charset
+	}
+
+	if table.Collation != "" {
+		statement += " COLLATE=" + table.Collation
+	}
+
+	return statement
+}
+
+func TruncateTableSQL(table string) string {
+	return fmt.Sprintf("TRUNCATE TABLE %s", QuoteIdentifier(table))
+}
+
+func DropTableSQL(table string) string {
+	return fmt.Sprintf("DROP TABLE %s", QuoteIdentifier(table))
+}
+
+func RenameTableSQL(table string, newName string) string {
+	return fmt.Sprintf("RENAME TABLE %s TO %s", QuoteIdentifier(table), QuoteIdentifier(newName))
+}
+
+// DuplicateTableSQL returns the statements that copy the structure of the
+// table, including indexes, and its rows when withData is set
+func DuplicateTableSQL(table string, newName string, withData bool) []string {
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s LIKE %s", QuoteIdentifier(newName), QuoteIdentifier(table)),
+	}
+
+	if withData {
+		statements = append(statements, fmt.Sprintf(
+			"INSERT INTO %s SELECT * FROM %s",
+			QuoteIdentifier(newName),
+			QuoteIdentifier(table),
+		))
+	}
+
+	return statements
+}
@@ -0,0 +1,474 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ForeignKey is a foreign key constraint of a table
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+}
+
+// SQL renders the constraint, e.g. CONSTRAINT `fk` FOREIGN KEY (`a`) REFERENCES `b` (`id`)
+func (f ForeignKey) SQL() string {
+	statement := fmt.Sprintf(
+		"CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		QuoteIdentifier(f.Name),
+		quoteIdentifiers(f.Columns),
+		QuoteIdentifier(f.RefTable),
+		quoteIdentifiers(f.RefColumns),
+	)
+
+	// RESTRICT and NO ACTION are the defaults
+	if f.OnDelete != "" && f.OnDelete != "RESTRICT" && f.OnDelete != "NO ACTION" {
+		statement += " ON DELETE " + f.OnDelete
+	}
+
+	if f.OnUpdate != "" && f.OnUpdate != "RESTRICT" && f.OnUpdate != "NO ACTION" {
+		statement += " ON UPDATE " + f.OnUpdate
+	}
+
+	return statement
+}
+
+func AddForeignKeySQL(table string, foreignKey ForeignKey) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", QuoteIdentifier(table), foreignKey.SQL())
+}
+
+func DropForeignKeySQL(table string, name string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", QuoteIdentifier(table), QuoteIdentifier(name))
+}
+
+// AddPrimaryKeySQL adds the PRIMARY index, AddIndexSQL can't name it
+func AddPrimaryKeySQL(table string, index IndexDefinition) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", QuoteIdentifier(table), index.columnsSQL())
+}
+
+// GetForeignKeys returns the foreign keys of the table in the current database
+func (client *DBClient) GetForeignKeys(table string) ([]ForeignKey, error) {
+	rows, err := client.db.Query(`
+		SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME,
+			r.UPDATE_RULE, r.DELETE_RULE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+		WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION`,
+		table,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get foreign keys of %s: %w", table, err)
+	}
+
+	defer rows.Close()
+
+	var foreignKeys []ForeignKey
+
+	for rows.Next() {
+		var name, column, refTable, refColumn string
+		var onUpdate, onDelete sql.NullString
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return nil, fmt.Errorf("Failed to scan foreign key: %w", err)
+		}
+
+		// one row per column of the key
+		if n := len(foreignKeys); n > 0 && foreignKeys[n-1].Name == name {
+			foreignKeys[n-1].Columns = append(foreignKeys[n-1].Columns, column)
+			foreignKeys[n-1].RefColumns = append(foreignKeys[n-1].RefColumns, refColumn)
+			continue
+		}
+
+		foreignKeys = append(foreignKeys, ForeignKey{
+			Name:       name,
+			Columns:    []string{column},
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+			OnUpdate:   onUpdate.String,
+			OnDelete:   onDelete.String,
+		})
+	}
+
+	return foreignKeys, rows.Err()
+}
+
+// IndexDefinitions converts the output of GetIndexes (SHOW INDEXES, with
+// the header in the first row) to one definition per index
+func IndexDefinitions(indexes [][]string) []IndexDefinition {
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	header := indexes[0]
+	column := func(row []string, name string) string {
+		if i := slices.Index(header, name); i >= 0 && i < len(row) {
+			return row[i]
+		}
+
+		return ""
+	}
+
+	var definitions []IndexDefinition
+
+	for _, row := range indexes[1:] {
+		name := column(row, "Key_name")
+
+		i := slices.IndexFunc(definitions, func(d IndexDefinition) bool { return d.Name == name })
+		if i < 0 {
+			definition := IndexDefinition{
+				Name:   name,
+				Unique: column(row, "Non_unique") == "0",
+			}
+
+			// BTREE and HASH are chosen by the storage engine
+			switch indexType := column(row, "Index_type"); indexType {
+			case "FULLTEXT", "SPATIAL":
+				definition.Type = indexType
+			}
+
+			definitions = append(definitions, definition)
+			i = len(definitions) - 1
+		}
+
+		definition := &definitions[i]
+
+		// rows are ordered by Seq_in_index, but don't rely on it
+		seq, _ := strconv.Atoi(column(row, "Seq_in_index"))
+		if seq < 1 {
+			seq = len(definition.Columns) + 1
+		}
+
+		for len(definition.Columns) < seq {
+			definition.Columns = append(definition.Columns, "")
+			definition.Lengths = append(definition.Lengths, 0)
+		}
+
+		// Sub_part is NULL unless only a prefix of the column is indexed
+		length, _ := strconv.Atoi(column(row, "Sub_part"))
+
+		definition.Columns[seq-1] = column(row, "Column_name")
+		definition.Lengths[seq-1] = length
+	}
+
+	return definitions
+}
+
+// TableSchema is the structure of a table that SchemaDiff compares
+type TableSchema struct {
+	Name        string
+	Columns     []Column
+	Indexes     []IndexDefinition
+	ForeignKeys []ForeignKey
+}
+
+func (client *DBClient) GetTableSchema(table string) (*TableSchema, error) {
+	columns, err := client.GetColumns(QuoteIdentifier(table))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get columns of %s: %w", table, err)
+	}
+
+	indexes, err := client.GetIndexes(QuoteIdentifier(table))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get indexes of %s: %w", table, err)
+	}
+
+	foreignKeys, err := client.GetForeignKeys(table)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TableSchema{
+		Name:        table,
+		Columns:     columns,
+		Indexes:     IndexDefinitions(indexes),
+		ForeignKeys: foreignKeys,
+	}, nil
+}
+
+// SchemaChange is a difference between the source and the target schema,
+// with the statements that change the target to match the source
+type SchemaChange struct {
+	Table       string
+	Kind        string
+	Description string
+	Statements  []string
+}
+
+// autoIncrementOption is the counter in SHOW CREATE TABLE, which is data
+// rather than schema
+var autoIncrementOption = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// DiffSchema compares the tables of the current database of the source and
+// the target connection. Views and routines are not compared.
+func DiffSchema(source *DBClient, target *DBClient) ([]SchemaChange, error) {
+	sourceTables, err := source.getBaseTables()
+	if err != nil {
+		return nil, err
+	}
+
+	targetTables, err := target.getBaseTables()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []SchemaChange
+
+	for _, table := range sourceTables {
+		if !slices.Contains(targetTables, table) {
+			createTable, err := source.GetCreateTable(table)
+			if err != nil {
+				return nil, err
+			}
+
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "missing table",
+				Description: "only in the source",
+				Statements:  []string{autoIncrementOption.ReplaceAllString(createTable, "")},
+			})
+			continue
+		}
+
+		sourceSchema, err := source.GetTableSchema(table)
+		if err != nil {
+			return nil, err
+		}
+
+		targetSchema, err := target.GetTableSchema(table)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, DiffTableSchema(sourceSchema, targetSchema)...)
+	}
+
+	for _, table := range targetTables {
+		if !slices.Contains(sourceTables, table) {
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "extra table",
+				Description: "only in the target",
+				Statements:  []string{DropTableSQL(table)},
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// getBaseTables returns the tables of the current database without views
+func (client *DBClient) getBaseTables() ([]string, error) {
+	objects, err := client.GetObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	for _, object := range objects {
+		if object.Type == ObjectTable {
+			tables = append(tables, object.Name)
+		}
+	}
+
+	return tables, nil
+}
+
+// DiffTableSchema compares the columns, indexes and foreign keys of a
+// table. Foreign keys are dropped first and added last, so they don't
+// block the changes of their columns and indexes.
+func DiffTableSchema(source *TableSchema, target *TableSchema) []SchemaChange {
+	table := source.Name
+
+	var drops, changes, adds []SchemaChange
+
+	for _, foreignKey := range target.ForeignKeys {
+		i := slices.IndexFunc(source.ForeignKeys, func(f ForeignKey) bool { return f.Name == foreignKey.Name })
+
+		switch {
+		case i < 0:
+			drops = append(drops, SchemaChange{
+				Table:       table,
+				Kind:        "extra foreign key",
+				Description: foreignKey.SQL(),
+				Statements:  []string{DropForeignKeySQL(table, foreignKey.Name)},
+			})
+		case source.ForeignKeys[i].SQL() != foreignKey.SQL():
+			drops = append(drops, SchemaChange{
+				Table:       table,
+				Kind:        "changed foreign key",
+				Description: fmt.Sprintf("%s, was %s", source.ForeignKeys[i].SQL(), foreignKey.SQL()),
+				Statements:  []string{DropForeignKeySQL(table, foreignKey.Name)},
+			})
+			adds = append(adds, SchemaChange{
+				Table:       table,
+				Kind:        "changed foreign key",
+				Description: source.ForeignKeys[i].SQL(),
+				Statements:  []string{AddForeignKeySQL(table, source.ForeignKeys[i])},
+			})
+		}
+	}
+
+	for _, foreignKey := range source.ForeignKeys {
+		if !slices.ContainsFunc(target.ForeignKeys, func(f ForeignKey) bool { return f.Name == foreignKey.Name }) {
+			adds = append(adds, SchemaChange{
+				Table:       table,
+				Kind:        "missing foreign key",
+				Description: foreignKey.SQL(),
+				Statements:  []string{AddForeignKeySQL(table, foreignKey)},
+			})
+		}
+	}
+
+	for i, column := range source.Columns {
+		j := slices.IndexFunc(target.Columns, func(c Column) bool { return c.Name == column.Name })
+
+		if j < 0 {
+			after := ""
+			if i > 0 {
+				after = source.Columns[i-1].Name
+			}
+
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "missing column",
+				Description: column.Definition().SQL(),
+				Statements:  []string{AddColumnSQL(table, column.Definition(), after)},
+			})
+			continue
+		}
+
+		if differences := columnDifferences(column, target.Columns[j]); len(differences) > 0 {
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "changed column",
+				Description: fmt.Sprintf("%s: %s", column.Name, strings.Join(differences, ", ")),
+				Statements:  []string{ModifyColumnSQL(table, column.Definition())},
+			})
+		}
+	}
+
+	for _, column := range target.Columns {
+		if !slices.ContainsFunc(source.Columns, func(c Column) bool { return c.Name == column.Name }) {
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "extra column",
+				Description: column.Definition().SQL(),
+				Statements:  []string{DropColumnSQL(table, column.Name)},
+			})
+		}
+	}
+
+	for _, index := range source.Indexes {
+		i := slices.IndexFunc(target.Indexes, func(d IndexDefinition) bool { return d.Name == index.Name })
+
+		switch {
+		case i < 0:
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "missing index",
+				Description: indexDescription(index),
+				Statements:  []string{addIndexSQL(table, index)},
+			})
+		case !sameIndex(index, target.Indexes[i]):
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "changed index",
+				Description: fmt.Sprintf("%s, was %s", indexDescription(index), indexDescription(target.Indexes[i])),
+				Statements:  []string{DropIndexSQL(table, index.Name), addIndexSQL(table, index)},
+			})
+		}
+	}
+
+	for _, index := range target.Indexes {
+		if !slices.ContainsFunc(source.Indexes, func(d IndexDefinition) bool { return d.Name == index.Name }) {
+			changes = append(changes, SchemaChange{
+				Table:       table,
+				Kind:        "extra index",
+				Description: indexDescription(index),
+				Statements:  []string{DropIndexSQL(table, index.Name)},
+			})
+		}
+	}
+
+	return slices.Concat(drops, changes, adds)
+}
+
+// columnDifferences describes how the target column differs from the
+// source column, e.g. "type int -> bigint"
+func columnDifferences(source Column, target Column) []string {
+	var differences []string
+
+	if !strings.EqualFold(source.DataType, target.DataType) {
+		differences = append(differences, fmt.Sprintf("type %s -> %s", target.DataType, source.DataType))
+	}
+
+	if source.Null != target.Null {
+		differences = append(differences, fmt.Sprintf("nullable %t -> %t", target.Null, source.Null))
+	}
+
+	if source.Default != target.Default {
+		differences = append(differences, fmt.Sprintf(
+			"default %s -> %s",
+			nullableDefault(target.Default),
+			nullableDefault(source.Default),
+		))
+	}
+
+	if !strings.EqualFold(source.Extra, target.Extra) {
+		differences = append(differences, fmt.Sprintf("extra %q -> %q", target.Extra, source.Extra))
+	}
+
+	if !strings.EqualFold(source.Collation.String, target.Collation.String) {
+		differences = append(differences, fmt.Sprintf(
+			"collation %s -> %s",
+			target.Collation.String,
+			source.Collation.String,
+		))
+	}
+
+	if source.Comment != target.Comment {
+		differences = append(differences, fmt.Sprintf("comment %q -> %q", target.Comment, source.Comment))
+	}
+
+	return differences
+}
+
+func nullableDefault(value sql.NullString) string {
+	if !value.Valid {
+		return "none"
+	}
+
+	return QuoteString(value.String)
+}
+
+func sameIndex(a IndexDefinition, b IndexDefinition) bool {
+	return a.Unique == b.Unique &&
+		a.Type == b.Type &&
+		slices.Equal(a.Columns, b.Columns) &&
+		slices.Equal(a.Lengths, b.Lengths)
+}
+
+func indexDescription(index IndexDefinition) string {
+	if index.Name == "PRIMARY" {
+		return fmt.Sprintf("PRIMARY KEY (%s)", index.columnsSQL())
+	}
+
+	return index.SQL()
+}
+
+func addIndexSQL(table string, index IndexDefinition) string {
+	if index.Name == "PRIMARY" {
+		return AddPrimaryKeySQL(table, index)
+	}
+
+	return AddIndexSQL(table, index)
+}
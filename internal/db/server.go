@@ -0,0 +1,190 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Process is a connection to the server from SHOW FULL PROCESSLIST. Query
+// is empty for idle connections.
+type Process struct {
+	ID      int64
+	User    string
+	Host    string
+	DB      string
+	Command string
+	Time    int64
+	State   string
+	Query   string
+}
+
+// GetProcesses returns the connections to the server, only the own
+// connections unless the user has the PROCESS privilege
+func (client *DBClient) GetProcesses() ([]Process, error) {
+	result, err := client.RunQuery("SHOW FULL PROCESSLIST")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get process list: %w", err)
+	}
+
+	var processes []Process
+
+	for _, row := range result.Rows {
+		var process Process
+
+		for i, column := range result.Columns {
+			value, _ := row[i].(string)
+
+			switch strings.ToLower(column) {
+			case "id":
+				process.ID, _ = strconv.ParseInt(value, 10, 64)
+			case "user":
+				process.User = value
+			case "host":
+				process.Host = value
+			case "db":
+				process.DB = value
+			case "command":
+				process.Command = value
+			case "time":
+				process.Time, _ = strconv.ParseInt(value, 10, 64)
+			case "state":
+				process.State = value
+			case "info":
+				process.Query = value
+			}
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+// KillSQL returns the statement that stops the query of the connection, or
+// closes the whole connection
+func KillSQL(id int64, queryOnly bool) string {
+	if queryOnly {
+		return fmt.Sprintf("KILL QUERY %d", id)
+	}
+
+	return fmt.Sprintf("KILL %d", id)
+}
+
+// GetGlobalStatus returns the server status counters, e.g. Uptime or Queries
+func (client *DBClient) GetGlobalStatus() (map[string]string, error) {
+	return client.getNameValues("SHOW GLOBAL STATUS")
+}
+
+// GetGlobalVariables returns the server variables, e.g. version or max_connections
+func (client *DBClient) GetGlobalVariables() (map[string]string, error) {
+	return client.getNameValues("SHOW GLOBAL VARIABLES")
+}
+
+func (client *DBClient) getNameValues(query string) (map[string]string, error) {
+	rows, err := client.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to run %s: %w", query, err)
+	}
+
+	defer rows.Close()
+
+	values := map[string]string{}
+
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("Failed to scan %s: %w", query, err)
+		}
+
+		values[name] = value.String
+	}
+
+	return values, rows.Err()
+}
+
+// Account is a database user, e.g. 'app'@'%'
+type Account struct {
+	User string
+	Host string
+}
+
+// String returns the quoted account name, e.g. 'app'@'%'
+func (a Account) String() string {
+	return QuoteString(a.User) + "@" + QuoteString(a.Host)
+}
+
+// GetAccounts returns the users of the server, it needs access to mysql.user
+func (client *DBClient) GetAccounts() ([]Account, error) {
+	rows, err := client.db.Query("SELECT User, Host FROM mysql.user ORDER BY User, Host")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get users: %w", err)
+	}
+
+	defer rows.Close()
+
+	var accounts []Account
+
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(&account.User, &account.Host); err != nil {
+			return nil, fmt.Errorf("Failed to scan user: %w", err)
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// GetGrants returns the GRANT statements of the account
+func (client *DBClient) GetGrants(account Account) ([]string, error) {
+	rows, err := client.db.Query("SHOW GRANTS FOR " + account.String())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get grants for %s: %w", account, err)
+	}
+
+	defer rows.Close()
+
+	var grants []string
+
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return nil, fmt.Errorf("Failed to scan grant: %w", err)
+		}
+
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+func CreateUserSQL(account Account, password string) string {
+	return fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s", account, QuoteString(password))
+}
+
+// GrantSQL grants privileges, e.g. "SELECT, INSERT", on an object, e.g. app.*
+func GrantSQL(privileges string, object string, account Account) string {
+	return fmt.Sprintf("GRANT %s ON %s TO %s", privileges, object, account)
+}
+
+func RevokeSQL(privileges string, object string, account Account) string {
+	return fmt.Sprintf("REVOKE %s ON %s FROM %s", privileges, object, account)
+}
+
+var grantStatement = regexp.MustCompile(`(?is)^GRANT\s+(.+?)\s+ON\s+(.+?)\s+TO\s+`)
+
+// ParseGrant returns the privileges and the object of a GRANT statement from
+// SHOW GRANTS, e.g. "SELECT, INSERT" and `app`.*
+func ParseGrant(grant string) (string, string, bool) {
+	match := grantStatement.FindStringSubmatch(grant)
+	if match == nil {
+		return "", "", false
+	}
+
+	return match[1], match[2], true
+}
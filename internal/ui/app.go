@@ -1,8 +1,11 @@
 package ui
 
 import (
-	"strconv"
+	"cmp"
+	"fmt"
+	"slices"
 
+	"github.com/alfonzm/lazydb/internal/config"
 	"github.com/alfonzm/lazydb/internal/db"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -16,8 +19,12 @@ type App struct {
 	tabPages        *tview.Pages
 	tabs            []*Tab
 	currentTabIndex int
+	nextTabID       int
 	dbClient        *db.DBClient
+	pools           *Pools
 	errorModal      *ErrorModal
+	confirmModal    *ConfirmModal
+	modalFocus      map[string]tview.Primitive
 }
 
 func Start() error {
@@ -30,6 +37,10 @@ func Start() error {
 	if err != nil {
 		return err
 	}
+	confirmModal, err := NewConfirmModal()
+	if err != nil {
+		return err
+	}
 
 	app := &App{
 		Application:  application,
@@ -38,11 +49,13 @@ func Start() error {
 		tabHeaders:   tabHeaders,
 		tabPages:     tabPages,
 		errorModal:   errorModal,
+		confirmModal: confirmModal,
+		modalFocus:   map[string]tview.Primitive{},
+		pools:        NewPools(),
 	}
 
 	errorModal.app = app
-
-	app.addNewTab()
+	confirmModal.app = app
 
 	container.AddItem(tabHeaders, 1, 0, false)
 	container.AddItem(tabPages, 0, 1, true)
@@ -50,36 +63,260 @@ func Start() error {
 	appPages.AddPage("app", container, true, true)
 
 	app.setKeyBindings()
+	app.SetRoot(appPages, true)
 
-	if err := app.SetRoot(appPages, true).Run(); err != nil {
+	// Reopen the tabs of the last session, errors are shown on top of the app
+	session, err := config.LoadSession()
+	if err != nil {
+		app.ShowError(fmt.Sprintf("%v", err))
+	} else if session != nil {
+		app.restoreSession(session)
+	}
+
+	if len(app.tabs) == 0 {
+		app.addNewTab()
+	}
+
+	if err := app.Run(); err != nil {
 		return err
 	}
-	return nil
+
+	return app.saveSession()
 }
 
 func (app *App) addNewTab() {
-	currentTab := app.currentTab()
-	if currentTab != nil {
-		currentTab.OnDeactivate()
+	tab, err := NewTab(app, app.dbClient)
+	if err != nil {
+		return
+	}
+
+	app.onDeactivateCurrentTab()
+
+	app.insertTab(len(app.tabs), tab)
+	app.selectTab(len(app.tabs) - 1)
+	app.RenderTabHeaders()
+}
+
+// insertTab adds the tab at the index without selecting it
+func (app *App) insertTab(index int, tab *Tab) {
+	tab.id = app.nextTabID
+	app.nextTabID++
+
+	app.tabs = slices.Insert(app.tabs, index, tab)
+	app.tabPages.AddPage(tab.pageName(), tab.pages, true, false)
+}
+
+// closeTab closes the current tab and its connection. Closing the last tab
+// opens a new one.
+func (app *App) closeTab() {
+	tab := app.currentTab()
+	if tab == nil {
+		return
+	}
+
+	tab.Close()
+
+	app.tabPages.RemovePage(tab.pageName())
+	app.tabs = slices.Delete(app.tabs, app.currentTabIndex, app.currentTabIndex+1)
+
+	if len(app.tabs) == 0 {
+		app.addNewTab()
+		return
+	}
+
+	app.RenderTabHeaders()
+	app.selectTab(min(app.currentTabIndex, len(app.tabs)-1))
+}
+
+// duplicateTab opens a copy of the current tab next to it, with the same
+// connection, table, filter, sort and page
+func (app *App) duplicateTab() {
+	current := app.currentTab()
+	if current == nil || current.connectionName == "" {
+		return
 	}
 
 	tab, err := NewTab(app, app.dbClient)
 	if err != nil {
+		app.ShowError(fmt.Sprintf("%v", err))
 		return
 	}
 
-	newTabIndex := len(app.tabs)
+	session := current.Session()
+	session.Name = ""
+	tab.pendingSession = &session
 
-	app.tabs = append(app.tabs, tab)
+	app.onDeactivateCurrentTab()
 
-	app.tabPages.AddPage(strconv.Itoa(newTabIndex), tab.pages, true, true)
-	app.selectTab(newTabIndex)
+	app.insertTab(app.currentTabIndex+1, tab)
 	app.RenderTabHeaders()
+	app.selectTab(app.currentTabIndex + 1)
+}
+
+// moveTab moves the current tab to the left (-1) or right (1)
+func (app *App) moveTab(offset int) {
+	target := app.currentTabIndex + offset
+	if target < 0 || target >= len(app.tabs) {
+		return
+	}
+
+	app.tabs[app.currentTabIndex], app.tabs[target] = app.tabs[target], app.tabs[app.currentTabIndex]
+	app.currentTabIndex = target
+
+	app.RenderTabHeaders()
+	app.tabHeaders.Select(0, target)
+}
+
+// jumpToTab selects the tab at the index, e.g. with Alt+1
+func (app *App) jumpToTab(index int) {
+	if index == app.currentTabIndex || index >= len(app.tabs) {
+		return
+	}
+
+	app.onDeactivateCurrentTab()
+	app.selectTab(index)
+}
+
+// showRenameTab asks for the name of the current tab, an empty name shows
+// the connection and table again
+func (app *App) showRenameTab() {
+	tab := app.currentTab()
+	if tab == nil {
+		return
+	}
+
+	form := newModalForm(app, "tab-name", "Rename tab")
+	form.AddInputField("Name", tab.customName, 30, nil, nil)
+
+	save := func() {
+		tab.customName = formText(form, "Name")
+		app.CloseModal("tab-name")
+		app.RenderTabHeaders()
+	}
+
+	// Enter saves, there is only one field
+	form.GetFormItemByLabel("Name").(*tview.InputField).
+		SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEnter {
+				save()
+				return nil
+			}
+
+			return event
+		})
+
+	form.AddButton("Save", save)
+	form.AddButton("Cancel", func() {
+		app.CloseModal("tab-name")
+	})
+
+	app.ShowModal("tab-name", form, 50, 7)
+}
+
+// saveSession saves the open tabs to restore them on the next start
+func (app *App) saveSession() error {
+	session := config.Session{CurrentTab: app.currentTabIndex}
+
+	for _, tab := range app.tabs {
+		session.Tabs = append(session.Tabs, tab.Session())
+	}
+
+	return config.SaveSession(session)
+}
+
+// restoreSession opens the tabs of a saved session, each tab reconnects
+// when it's first shown
+func (app *App) restoreSession(session *config.Session) {
+	for _, tabSession := range session.Tabs {
+		tab, err := NewTab(app, app.dbClient)
+		if err != nil {
+			continue
+		}
+
+		tab.customName = tabSession.Name
+
+		if tabSession.Connection != "" {
+			tab.pendingSession = &tabSession
+			tab.connectionName = tabSession.Connection
+			tab.name = cmp.Or(tabSession.Table, tabSession.Database)
+
+			// for the label and color in the tab header
+			if connection, err := config.GetConnection(tabSession.Connection); err == nil {
+				tab.connection = connection
+			}
+		}
+
+		app.insertTab(len(app.tabs), tab)
+	}
+
+	if len(app.tabs) == 0 {
+		return
+	}
+
+	currentTab := session.CurrentTab
+	if currentTab < 0 || currentTab >= len(app.tabs) {
+		currentTab = 0
+	}
+
+	app.RenderTabHeaders()
+	app.selectTab(currentTab)
+}
+
+// loadSession replaces the open tabs with the saved session
+func (app *App) loadSession() {
+	session, err := config.LoadSession()
+	if err != nil {
+		app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	if session == nil || len(session.Tabs) == 0 {
+		app.ShowError(fmt.Sprintf("No saved session at %s", config.SessionPath()))
+		return
+	}
+
+	text := fmt.Sprintf("Close the %d open tabs and open the %d saved tabs?", len(app.tabs), len(session.Tabs))
+
+	app.Confirm("Load session", text, func() {
+		app.closeAllTabs()
+		app.restoreSession(session)
+
+		if len(app.tabs) == 0 {
+			app.addNewTab()
+		}
+	})
+}
+
+// closeAllTabs closes the tabs and their connections
+func (app *App) closeAllTabs() {
+	for _, tab := range app.tabs {
+		tab.Close()
+		app.tabPages.RemovePage(tab.pageName())
+	}
+
+	app.tabs = nil
+	app.currentTabIndex = 0
+	app.tabHeaders.Clear()
 }
 
 func (app *App) RenderTabHeaders() {
+	// tabs can be closed, remove the headers that are left over
+	app.tabHeaders.Clear()
+
 	for i, tab := range app.tabs {
-		app.tabHeaders.SetCell(0, i, tview.NewTableCell(tab.name))
+		name := connectionLabel(tab.connection) + connectionName(tab.Title(), tab.connection)
+
+		// the number to jump to the tab with Alt
+		if i < 9 {
+			name = fmt.Sprintf("[gray]%d[-] %s", i+1, name)
+		}
+
+		// make it obvious when a tab is connected to a protected database
+		if tab.ReadOnly() {
+			name = fmt.Sprintf("%s [white:red] PROD / READ-ONLY [-:-]", name)
+		}
+
+		app.tabHeaders.SetCell(0, i, tview.NewTableCell(name))
 	}
 }
 
@@ -127,7 +364,7 @@ func (app *App) selectTab(newTabIndex int) {
 	app.currentTabIndex = newTabIndex
 
 	app.tabHeaders.Select(0, app.currentTabIndex)
-	app.tabPages.SwitchToPage(strconv.Itoa(app.currentTabIndex))
+	app.tabPages.SwitchToPage(app.currentTab().pageName())
 
 	newSelectedTab := app.currentTab()
 	newSelectedTab.OnActivate()
@@ -137,15 +374,25 @@ func (app *App) setKeyBindings() {
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		currentTab := app.currentTab()
 
-		// If the focus is on an input/textarea field, early return
-		if _, ok := app.GetFocus().(*tview.InputField); ok {
+		// If a modal is open, let it handle the keys
+		if frontPage, _ := app.appPages.GetFrontPage(); frontPage != "app" {
 			return event
 		}
 
-		if _, ok := app.GetFocus().(*tview.TextArea); ok {
+		// If the focus is on an input/textarea field or a form, early return
+		switch app.GetFocus().(type) {
+		case *tview.InputField, *tview.TextArea, *tview.Button, *tview.Checkbox:
 			return event
 		}
 
+		// Alt+1 to Alt+9 jump to the tab
+		if event.Key() == tcell.KeyRune && event.Modifiers()&tcell.ModAlt != 0 {
+			if event.Rune() >= '1' && event.Rune() <= '9' {
+				app.jumpToTab(int(event.Rune() - '1'))
+				return nil
+			}
+		}
+
 		if event.Key() == tcell.KeyRune {
 			switch event.Rune() {
 
@@ -156,18 +403,48 @@ func (app *App) setKeyBindings() {
 				app.nextTab()
 			case 't':
 				app.addNewTab()
+			case '{':
+				app.moveTab(-1)
+			case '}':
+				app.moveTab(1)
+			case 'R':
+				// don't type the R in the name field
+				app.showRenameTab()
+				return nil
+
+			// Database management
+			case 'D':
+				currentTab.ShowDatabasePicker()
 
 				// App management
 			case 'q':
 				app.Stop()
+			case 'L':
+				app.loadSession()
+			case 'P':
+				app.ShowMessage("Connection pools", app.pools.Stats())
 
 			// Current tab hotkeys
 			case '0':
-				currentTab.pages.SwitchToPage("connections")
+				currentTab.ShowConnections()
+			case '|':
+				currentTab.ShowSplit()
+			case '\\':
+				currentTab.ToggleSplitLayout()
+			case 'S':
+				currentTab.ToggleSplitSync()
+			case '=':
+				currentTab.CompareSchema()
 			}
 		}
 
 		switch event.Key() {
+		case tcell.KeyCtrlW:
+			app.closeTab()
+			return nil
+		case tcell.KeyCtrlT:
+			app.duplicateTab()
+			return nil
 		case tcell.KeyCtrlF:
 			currentTab.FocusFindTable()
 		case tcell.KeyTab:
@@ -181,3 +458,38 @@ func (app *App) setKeyBindings() {
 func (app *App) ShowError(errorText string) {
 	app.errorModal.RenderError(errorText)
 }
+
+func (app *App) ShowMessage(title string, text string) {
+	app.errorModal.RenderMessage(title, text)
+}
+
+// ShowModal shows the primitive (e.g. a form) centered on top of the app
+func (app *App) ShowModal(name string, p tview.Primitive, width, height int) {
+	if _, ok := app.modalFocus[name]; !ok {
+		app.modalFocus[name] = app.GetFocus()
+	}
+
+	app.appPages.RemovePage(name)
+	app.appPages.AddPage(name, centered(p, width, height), true, true)
+	app.SetFocus(p)
+}
+
+// CloseModal closes a modal opened with ShowModal and restores the focus
+func (app *App) CloseModal(name string) {
+	app.appPages.RemovePage(name)
+
+	if focus, ok := app.modalFocus[name]; ok {
+		app.SetFocus(focus)
+		delete(app.modalFocus, name)
+	}
+}
+
+// Confirm asks the user to confirm before running onConfirm
+func (app *App) Confirm(title string, text string, onConfirm func()) {
+	app.confirmModal.Confirm(title, text, onConfirm)
+}
+
+// ConfirmTyped asks the user to type the expected text before running onConfirm
+func (app *App) ConfirmTyped(title string, text string, expected string, onConfirm func()) {
+	app.confirmModal.ConfirmTyped(title, text, expected, onConfirm)
+}
@@ -0,0 +1,69 @@
+package ui
+
+import "time"
+
+// autoRefresh runs load in the background every interval until Stop is
+// called, then the update it returns on the UI goroutine. A slow server
+// delays the next refresh instead of freezing the app.
+type autoRefresh struct {
+	app      *App
+	interval time.Duration
+	load     func() func()
+	stop     chan struct{}
+}
+
+func newAutoRefresh(app *App, interval time.Duration, load func() func()) *autoRefresh {
+	return &autoRefresh{
+		app:      app,
+		interval: interval,
+		load:     load,
+	}
+}
+
+// Start refreshes every interval, the first time after one interval
+func (a *autoRefresh) Start() {
+	a.Stop()
+
+	stop := make(chan struct{})
+	a.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			update := a.load()
+
+			a.app.QueueUpdateDraw(func() {
+				// stopped while loading, e.g. the page was left
+				select {
+				case <-stop:
+				default:
+					update()
+				}
+			})
+		}
+	}()
+}
+
+func (a *autoRefresh) Stop() {
+	if a.stop != nil {
+		close(a.stop)
+		a.stop = nil
+	}
+}
+
+// loadInBackground runs load outside of the UI goroutine, then the update
+// it returns on the UI goroutine
+func loadInBackground(app *App, load func() func()) {
+	go func() {
+		update := load()
+		app.QueueUpdateDraw(update)
+	}()
+}
@@ -10,6 +10,7 @@ import (
 
 type CellEditor struct {
 	app      *App
+	page     string
 	results  *Results
 	view     *tview.Flex
 	textArea *tview.TextArea
@@ -18,6 +19,7 @@ type CellEditor struct {
 func NewCellEditor(
 	app *App,
 	pages *tview.Pages,
+	page string,
 	results *Results,
 	db *db.DBClient,
 ) (*CellEditor, error) {
@@ -31,8 +33,8 @@ func NewCellEditor(
 
 			// update the record in the DB
 			selectedRow, selectedColumn := results.resultsTable.GetSelection()
-			id := results.resultsTable.GetCell(selectedRow, 0).Text
-			colName := results.resultsTable.GetCell(0, selectedColumn).Text
+			id := fmt.Sprintf("%v", results.records[selectedRow-1]["id"])
+			colName := results.columns[selectedColumn].Name
 
 			record := make(map[string]interface{})
 			record[colName] = newText
@@ -45,7 +47,7 @@ func NewCellEditor(
 
 			// refresh the records table
 			results.RenderTable(results.selectedTable, results.filter.GetText())
-			pages.HidePage("editor")
+			pages.HidePage(page)
 			app.SetFocus(results.resultsTable)
 
 			// stay on the same cell
@@ -54,7 +56,7 @@ func NewCellEditor(
 
 		// on press escape, hide the record editor
 		if event.Key() == tcell.KeyEscape {
-			pages.HidePage("editor")
+			pages.HidePage(page)
 			app.SetFocus(results.resultsTable)
 		}
 
@@ -71,6 +73,7 @@ func NewCellEditor(
 
 	return &CellEditor{
 		app:      app,
+		page:     page,
 		view:     recordEditor,
 		results:  results,
 		textArea: textArea,
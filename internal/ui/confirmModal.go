@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type ConfirmModal struct {
+	app       *App
+	lastFocus tview.Primitive
+}
+
+func NewConfirmModal() (*ConfirmModal, error) {
+	return &ConfirmModal{}, nil
+}
+
+// Confirm shows the text in a modal and calls onConfirm when the user
+// presses Enter. Esc closes the modal without doing anything.
+func (c *ConfirmModal) Confirm(title string, text string, onConfirm func()) {
+	textView := c.newTextView(text)
+
+	legend := tview.NewTextView().
+		SetText("[Enter] Confirm / [Esc] Cancel").
+		SetTextColor(tcell.ColorYellow).
+		SetTextAlign(tview.AlignCenter)
+
+	container := tview.NewFlex()
+	container.SetBorder(true).
+		SetTitle(title)
+	container.SetDirection(tview.FlexRow).
+		AddItem(textView, 0, 1, true).
+		AddItem(legend, 1, 1, false)
+
+	container.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			c.close()
+			onConfirm()
+			return nil
+		case tcell.KeyEscape:
+			c.close()
+			return nil
+		}
+
+		return event
+	})
+
+	c.show(container, modalHeight(text, 1))
+}
+
+// ConfirmTyped works like Confirm but the user has to type the expected
+// text (e.g. a table name) before the action runs
+func (c *ConfirmModal) ConfirmTyped(title string, text string, expected string, onConfirm func()) {
+	textView := c.newTextView(text)
+
+	input := tview.NewInputField().
+		SetLabel(fmt.Sprintf("Type %s to confirm: ", expected)).
+		SetFieldBackgroundColor(tcell.ColorNone)
+
+	legend := tview.NewTextView().
+		SetText("[Enter] Confirm / [Esc] Cancel").
+		SetTextColor(tcell.ColorYellow).
+		SetTextAlign(tview.AlignCenter)
+
+	container := tview.NewFlex()
+	container.SetBorder(true).
+		SetTitle(title)
+	container.SetDirection(tview.FlexRow).
+		AddItem(textView, 0, 1, false).
+		AddItem(input, 1, 1, true).
+		AddItem(legend, 1, 1, false)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			if input.GetText() != expected {
+				input.SetFieldTextColor(tcell.ColorRed)
+				return
+			}
+			c.close()
+			onConfirm()
+		case tcell.KeyEscape:
+			c.close()
+		}
+	})
+
+	input.SetChangedFunc(func(text string) {
+		input.SetFieldTextColor(tcell.ColorWhite)
+	})
+
+	c.show(container, modalHeight(text, 2))
+}
+
+func (c *ConfirmModal) newTextView(text string) *tview.TextView {
+	return tview.NewTextView().
+		SetText(text).
+		SetDynamicColors(true).
+		SetWordWrap(true)
+}
+
+func (c *ConfirmModal) show(container tview.Primitive, height int) {
+	c.lastFocus = c.app.GetFocus()
+
+	c.app.appPages.RemovePage("confirm")
+	c.app.appPages.AddPage("confirm", centered(container, 100, height), true, true)
+	c.app.SetFocus(container)
+}
+
+func (c *ConfirmModal) close() {
+	c.app.appPages.RemovePage("confirm")
+	c.app.SetFocus(c.lastFocus)
+}
+
+// modalHeight fits the modal to the text, plus borders and the given
+// number of extra lines, within sensible bounds
+func modalHeight(text string, extraLines int) int {
+	height := strings.Count(text, "\n") + 1 + extraLines + 2
+
+	return max(7, min(height, 30))
+}
@@ -1,7 +1,10 @@
 package ui
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/alfonzm/lazydb/internal/config"
 	"github.com/alfonzm/lazydb/internal/db"
@@ -10,20 +13,18 @@ import (
 )
 
 type Connections struct {
-	view *tview.Flex
-	list *tview.List
-	tab  *Tab
+	view        *tview.Flex
+	list        *tview.List
+	form        *tview.Form
+	tab         *Tab
+	connections map[string]config.Connection
+	names       []string
 }
 
 func NewConnections(
 	tab *Tab,
 	db *db.DBClient,
 ) (*Connections, error) {
-	connConfigurations, err := config.GetConnections()
-	if err != nil {
-		return nil, err
-	}
-
 	list := tview.NewList()
 	view := tview.NewFlex()
 
@@ -34,26 +35,81 @@ func NewConnections(
 	}
 
 	list.SetBorder(true)
-	list.SetTitle("Select a connection")
 	list.ShowSecondaryText(false)
 
+	legend := tview.NewTextView().
+		SetText("[Enter] Connect  [n] New  [e] Edit  [c] Clone  [d] Delete  [p] Test connection").
+		SetTextColor(tcell.ColorYellow)
+
+	view.SetDirection(tview.FlexRow).
+		AddItem(list, 0, 1, true).
+		AddItem(legend, 1, 1, false)
+
+	if err := connections.Render(); err != nil {
+		return nil, err
+	}
+
+	connections.setKeyBindings()
+
+	return connections, nil
+}
+
+// Render (re)loads the connections from the config file into the list
+func (c *Connections) Render() error {
+	connConfigurations, err := config.GetConnections()
+	if err != nil {
+		// A missing config file is fine, connections can be added from the UI
+		if config.Exists() {
+			return err
+		}
+		connConfigurations = map[string]config.Connection{}
+	}
+
+	c.connections = connConfigurations
+
 	var connNames []string
 	for k := range connConfigurations {
 		connNames = append(connNames, k)
 	}
 	sort.Strings(connNames)
 
+	c.names = connNames
+
+	currentItem := c.list.GetCurrentItem()
+	c.list.Clear()
+
 	for _, dbName := range connNames {
 		conn := connConfigurations[dbName]
-		list.AddItem(conn.Database, "", 0, connections.selectConnection(conn.String(), dbName))
+
+		database := conn.Database
+		if database == "" {
+			database = "(choose after connecting)"
+		}
+
+		text := fmt.Sprintf(
+			"%s%s [gray]%s@%s:%d/%s",
+			connectionLabel(conn),
+			connectionName(dbName, conn),
+			tview.Escape(conn.User),
+			tview.Escape(conn.Host),
+			conn.Port,
+			tview.Escape(database),
+		)
+		if conn.ReadOnly {
+			text += " [red]read-only"
+		}
+		c.list.AddItem(text, "", 0, c.selectConnection(dbName, conn))
 	}
 
-	view.SetDirection(tview.FlexRow).
-		AddItem(list, 0, 1, true)
+	c.list.SetCurrentItem(currentItem)
 
-	connections.setKeyBindings()
+	if len(connNames) == 0 {
+		c.list.SetTitle("No connections yet, press n to add one")
+	} else {
+		c.list.SetTitle("Select a connection")
+	}
 
-	return connections, nil
+	return nil
 }
 
 func (c *Connections) setKeyBindings() {
@@ -69,14 +125,210 @@ func (c *Connections) setKeyBindings() {
 				}
 			case 'k':
 				c.list.SetCurrentItem(c.list.GetCurrentItem() - 1)
+			case 'n':
+				c.showForm("", "", config.Connection{Host: "127.0.0.1", Port: 3306})
+				return nil
+			case 'e':
+				if name, ok := c.selectedName(); ok {
+					c.showForm(name, name, c.connections[name])
+				}
+				return nil
+			case 'c':
+				if name, ok := c.selectedName(); ok {
+					c.showForm("", name+"-copy", c.connections[name])
+				}
+				return nil
+			case 'd':
+				if name, ok := c.selectedName(); ok {
+					c.deleteConnection(name)
+				}
+				return nil
+			case 'p':
+				if name, ok := c.selectedName(); ok {
+					connection := c.connections[name]
+					c.testConnection(name, connection)
+				}
+				return nil
 			}
 		}
 		return event
 	})
 }
 
-func (c *Connections) selectConnection(url string, dbName string) func() {
+func (c *Connections) selectConnection(name string, connection config.Connection) func() {
 	return func() {
-		c.tab.ConnectDatabase(url, dbName)
+		if err := c.tab.ConnectDatabase(name, connection); err != nil {
+			c.tab.app.ShowError(fmt.Sprintf("%v", err))
+		}
+	}
+}
+
+func (c *Connections) selectedName() (string, bool) {
+	if len(c.names) == 0 {
+		return "", false
+	}
+
+	return c.names[c.list.GetCurrentItem()], true
+}
+
+// showForm opens the connection form. oldName is the name of the entry being
+// edited, or empty when creating a new (or cloned) connection.
+func (c *Connections) showForm(oldName string, name string, connection config.Connection) {
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetFieldBackgroundColor(tcell.Color237)
+
+	if oldName == "" {
+		form.SetTitle("New connection")
+	} else {
+		form.SetTitle(fmt.Sprintf("Edit %s", oldName))
+	}
+
+	form.AddInputField("Name", name, 40, nil, nil).
+		AddInputField("Host", connection.Host, 40, nil, nil).
+		AddInputField("Port", strconv.Itoa(connection.Port), 6, tview.InputFieldInteger, nil).
+		AddInputField("User", connection.User, 40, nil, nil).
+		AddPasswordField("Password", connection.Password, 40, '*', nil).
+		AddInputField("Database", connection.Database, 40, nil, nil).
+		AddCheckbox("Read only", connection.ReadOnly, nil).
+		AddInputField("Label", connection.Label, 20, nil, nil).
+		AddInputField("Color", connection.Color, 20, nil, nil)
+
+	form.AddButton("Save", func() {
+		name, connection := c.formConnection()
+
+		if connection.Color != "" && tcell.GetColor(connection.Color) == tcell.ColorDefault {
+			c.tab.app.ShowError(fmt.Sprintf("Unknown color %q, use a color name or #rrggbb", connection.Color))
+			return
+		}
+
+		if err := config.SaveConnection(oldName, name, connection); err != nil {
+			c.tab.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		c.hideForm()
+
+		if err := c.Render(); err != nil {
+			c.tab.app.ShowError(fmt.Sprintf("%v", err))
+		}
+	})
+	form.AddButton("Test", func() {
+		name, connection := c.formConnection()
+		c.testConnection(name, connection)
+	})
+	form.AddButton("Cancel", c.hideForm)
+
+	form.SetCancelFunc(c.hideForm)
+
+	form.GetFormItemByLabel("Database").(*tview.InputField).
+		SetPlaceholder("empty to choose after connecting")
+
+	c.form = form
+
+	c.tab.pages.RemovePage("connection-form")
+	c.tab.pages.AddPage("connection-form", centered(form, 60, 23), true, true)
+	c.tab.app.SetFocus(form)
+}
+
+func (c *Connections) hideForm() {
+	c.tab.pages.RemovePage("connection-form")
+	c.tab.app.SetFocus(c.list)
+}
+
+// formConnection reads the connection name and configuration from the form
+func (c *Connections) formConnection() (string, config.Connection) {
+	text := func(label string) string {
+		return c.form.GetFormItemByLabel(label).(*tview.InputField).GetText()
+	}
+
+	port, _ := strconv.Atoi(text("Port"))
+
+	return text("Name"), config.Connection{
+		Host:     text("Host"),
+		Port:     port,
+		User:     text("User"),
+		Password: text("Password"),
+		Database: text("Database"),
+		ReadOnly: c.form.GetFormItemByLabel("Read only").(*tview.Checkbox).IsChecked(),
+		Label:    text("Label"),
+		Color:    text("Color"),
+	}
+}
+
+// testConnection connects in the background, an unreachable host takes
+// up to the timeout of db.TestConnection
+func (c *Connections) testConnection(name string, connection config.Connection) {
+	app := c.tab.app
+
+	progress := tview.NewTextView().
+		SetText(fmt.Sprintf("Connecting to %s...", tview.Escape(name)))
+	progress.SetBorder(true).
+		SetTitle("Test connection")
+
+	app.ShowModal("test-connection", progress, 50, 3)
+
+	go func() {
+		version, latency, err := db.TestConnection(connection.String())
+
+		app.QueueUpdateDraw(func() {
+			app.CloseModal("test-connection")
+
+			if err != nil {
+				app.ShowError(fmt.Sprintf("%s: %v", name, err))
+				return
+			}
+
+			app.ShowMessage(
+				"Connection OK",
+				fmt.Sprintf(
+					"Connected to %s\n\nServer version: %s\nLatency: %s",
+					name,
+					version,
+					latency.Round(10*time.Microsecond),
+				),
+			)
+		})
+	}()
+}
+
+func (c *Connections) deleteConnection(name string) {
+	c.tab.app.Confirm(
+		"Delete connection",
+		fmt.Sprintf("Delete connection %s from %s?", name, config.Path()),
+		func() {
+			if err := config.DeleteConnection(name); err != nil {
+				c.tab.app.ShowError(fmt.Sprintf("%v", err))
+				return
+			}
+
+			if err := c.Render(); err != nil {
+				c.tab.app.ShowError(fmt.Sprintf("%v", err))
+			}
+		},
+	)
+}
+
+// connectionLabel renders the label of the connection as a badge in the
+// connection color, so environments are easy to tell apart
+func connectionLabel(connection config.Connection) string {
+	if connection.Label == "" {
+		return ""
+	}
+
+	color := connection.Color
+	if color == "" {
+		color = "gray"
 	}
+
+	return fmt.Sprintf("[black:%s] %s [-:-] ", color, tview.Escape(connection.Label))
+}
+
+// connectionName renders the name in the connection color, if any
+func connectionName(name string, connection config.Connection) string {
+	if connection.Color == "" {
+		return tview.Escape(name)
+	}
+
+	return fmt.Sprintf("[%s]%s[-]", connection.Color, tview.Escape(name))
 }
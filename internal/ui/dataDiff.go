@@ -0,0 +1,300 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/atotto/clipboard"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// DataDiff shows the rows that differ between two tables, e.g. to verify a
+// migration or a backfill
+type DataDiff struct {
+	app         *App
+	diff        *db.DataDiff
+	source      string
+	target      string
+	targetTable string
+	onClose     func()
+	view        *tview.Flex
+	table       *tview.Table
+}
+
+// compareTableData compares the rows of the selected table with a table on
+// any connection. The rows are loaded in the background, both tables can
+// be large.
+func (s *Sidebar) compareTableData() {
+	object, ok := s.currentObject()
+	if !ok || object.Type != db.ObjectTable {
+		return
+	}
+
+	app := s.tab.app
+
+	pickTable(s.tab, "Compare data with", func(connectionName string, client *db.DBClient, table string) error {
+		source := fmt.Sprintf("%s: %s", s.tab.connectionName, object.Name)
+		target := fmt.Sprintf("%s: %s", connectionName, table)
+
+		progress := tview.NewTextView().
+			SetText(tview.Escape(fmt.Sprintf("Comparing %s with %s...", source, target)))
+		progress.SetBorder(true).
+			SetTitle("Data diff")
+
+		app.ShowModal("data-diff-progress", progress, 70, 3)
+
+		go func() {
+			diff, err := db.DiffData(s.db, object.Name, client, table)
+
+			app.QueueUpdateDraw(func() {
+				app.CloseModal("data-diff-progress")
+
+				if err != nil {
+					app.pools.Release(client)
+					app.ShowError(fmt.Sprintf("%v", err))
+					return
+				}
+
+				NewDataDiff(app, diff, source, target, table, func() {
+					app.pools.Release(client)
+				}).Show()
+			})
+		}()
+
+		return nil
+	})
+}
+
+func NewDataDiff(
+	app *App,
+	diff *db.DataDiff,
+	source string,
+	target string,
+	targetTable string,
+	onClose func(),
+) *DataDiff {
+	added, removed, changed := diff.Counts()
+
+	summary := fmt.Sprintf(
+		"[green]%d added[-], [red]%d removed[-], [yellow]%d changed[-], %d same, matched by %s",
+		added,
+		removed,
+		changed,
+		diff.Same,
+		strings.Join(diff.PrimaryKey, ", "),
+	)
+
+	if len(diff.Ignored) > 0 {
+		summary += fmt.Sprintf("\n[gray]Not in both tables: %s[-]", tview.Escape(strings.Join(diff.Ignored, ", ")))
+	}
+
+	header := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(summary)
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	legend := tview.NewTextView().
+		SetText("[Enter] Row details  [g] SQL to make the target match  [Esc] Close").
+		SetTextColor(tcell.ColorYellow)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(header, strings.Count(summary, "\n")+1, 0, false).
+		AddItem(table, 0, 1, true).
+		AddItem(legend, 1, 0, false)
+	view.SetBorder(true).
+		SetTitle(tview.Escape(fmt.Sprintf("Data diff: %s -> %s", source, target)))
+
+	dataDiff := &DataDiff{
+		app:         app,
+		diff:        diff,
+		source:      source,
+		target:      target,
+		targetTable: targetTable,
+		onClose:     onClose,
+		view:        view,
+		table:       table,
+	}
+
+	dataDiff.setKeyBindings()
+	dataDiff.render()
+
+	return dataDiff
+}
+
+func (d *DataDiff) Show() {
+	d.app.ShowModal("data-diff", d.view, 160, 40)
+	d.app.SetFocus(d.table)
+}
+
+func (d *DataDiff) setKeyBindings() {
+	d.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			d.close()
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			d.showRow()
+			return nil
+		case event.Rune() == 'g':
+			d.showSyncSQL()
+			return nil
+		}
+
+		return event
+	})
+}
+
+func (d *DataDiff) close() {
+	d.app.CloseModal("data-diff")
+
+	if d.onClose != nil {
+		d.onClose()
+	}
+}
+
+// render lists the rows that differ. Added and removed rows are green and
+// red, the changed cells of changed rows are yellow.
+func (d *DataDiff) render() {
+	d.table.Clear()
+
+	d.table.SetCell(0, 0, tview.NewTableCell("").SetSelectable(false))
+	for i, column := range d.diff.Columns {
+		d.table.SetCell(0, i+1, tview.NewTableCell(tview.Escape(column)).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, row := range d.diff.Rows {
+		status, color, values := "~", tcell.ColorDefault, row.Source
+
+		switch row.Status {
+		case db.RowAdded:
+			status, color = "+", tcell.ColorGreen
+		case db.RowRemoved:
+			status, color, values = "-", tcell.ColorRed, row.Target
+		}
+
+		d.table.SetCell(i+1, 0, tview.NewTableCell(status).SetTextColor(color))
+
+		for j, column := range d.diff.Columns {
+			cell := tview.NewTableCell(tview.Escape(diffValue(values[column]))).
+				SetTextColor(color).
+				SetMaxWidth(40)
+
+			if slices.Contains(row.Changed, column) {
+				cell.SetText(tview.Escape(fmt.Sprintf(
+					"%s -> %s",
+					diffValue(row.Target[column]),
+					diffValue(row.Source[column]),
+				))).SetTextColor(tcell.ColorYellow)
+			}
+
+			d.table.SetCell(i+1, j+1, cell)
+		}
+	}
+
+	if len(d.diff.Rows) == 0 {
+		d.table.SetCell(1, 0, tview.NewTableCell("The tables have the same rows").
+			SetSelectable(false))
+	}
+}
+
+// showRow shows the source and target values of every column of the
+// selected row
+func (d *DataDiff) showRow() {
+	row, _ := d.table.GetSelection()
+	if row < 1 || row > len(d.diff.Rows) {
+		return
+	}
+
+	rowDiff := d.diff.Rows[row-1]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[yellow]%-30s %-30s %s[-]\n", "Column", d.target, d.source)
+
+	for _, column := range d.diff.Columns {
+		target, source := "-", "-"
+		if rowDiff.Target != nil {
+			target = diffValue(rowDiff.Target[column])
+		}
+		if rowDiff.Source != nil {
+			source = diffValue(rowDiff.Source[column])
+		}
+
+		line := fmt.Sprintf("%-30s %-30s %s", column, target, source)
+		if slices.Contains(rowDiff.Changed, column) {
+			fmt.Fprintf(&sb, "[yellow]%s[-]\n", tview.Escape(line))
+		} else {
+			fmt.Fprintf(&sb, "%s\n", tview.Escape(line))
+		}
+	}
+
+	text := strings.TrimSuffix(sb.String(), "\n")
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(text)
+	textView.SetBorder(true).
+		SetTitle(fmt.Sprintf("Row (%s)", rowDiff.Status))
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyEnter {
+			d.app.CloseModal("data-diff-row")
+			return nil
+		}
+
+		return event
+	})
+
+	d.app.ShowModal("data-diff-row", textView, 100, modalHeight(text, 0))
+}
+
+// showSyncSQL shows the statements that make the target table match the
+// source table, y copies them
+func (d *DataDiff) showSyncSQL() {
+	statements := d.diff.SyncSQL(d.targetTable)
+	if len(statements) == 0 {
+		d.app.ShowMessage("Data diff", "The tables have the same rows")
+		return
+	}
+
+	script := strings.Join(statements, ";\n") + ";"
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(highlightSQL(script))
+	textView.SetBorder(true).
+		SetTitle(tview.Escape(fmt.Sprintf("SQL to make %s match (%d statements) - [y] Copy", d.target, len(statements))))
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			d.app.CloseModal("data-diff-sql")
+			return nil
+		case event.Rune() == 'y':
+			clipboard.WriteAll(script)
+			d.app.CloseModal("data-diff-sql")
+			return nil
+		}
+
+		return event
+	})
+
+	d.app.ShowModal("data-diff-sql", textView, 120, modalHeight(script, 0))
+}
+
+// diffValue renders a value from RunQuery, NULL for nil
+func diffValue(value any) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type DatabasePicker struct {
+	tab  *Tab
+	list *tview.List
+}
+
+func NewDatabasePicker(tab *Tab) (*DatabasePicker, error) {
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle("Select a database")
+	list.ShowSecondaryText(false).SetHighlightFullLine(true)
+
+	picker := &DatabasePicker{
+		tab:  tab,
+		list: list,
+	}
+
+	picker.setKeyBindings()
+
+	return picker, nil
+}
+
+// Show lists the databases of the tab's server (SHOW DATABASES)
+func (d *DatabasePicker) Show() error {
+	databases, err := d.tab.dbClient.GetDatabases()
+	if err != nil {
+		return err
+	}
+
+	d.list.Clear()
+
+	for i, database := range databases {
+		d.list.AddItem(database, "", 0, d.selectDatabase(database))
+
+		if database == d.tab.database {
+			d.list.SetCurrentItem(i)
+		}
+	}
+
+	d.tab.pages.RemovePage("databases")
+	d.tab.pages.AddPage("databases", centered(d.list, 50, 20), true, true)
+	d.tab.app.SetFocus(d.list)
+
+	return nil
+}
+
+func (d *DatabasePicker) hide() {
+	d.tab.pages.RemovePage("databases")
+
+	if d.tab.sidebar != nil {
+		d.tab.app.SetFocus(d.tab.sidebar.tree)
+	} else {
+		d.tab.app.SetFocus(d.tab.connections.list)
+	}
+}
+
+func (d *DatabasePicker) selectDatabase(database string) func() {
+	return func() {
+		d.hide()
+
+		if err := d.tab.SwitchDatabase(database); err != nil {
+			d.tab.app.ShowError(fmt.Sprintf("%v", err))
+		}
+	}
+}
+
+func (d *DatabasePicker) setKeyBindings() {
+	d.list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			d.hide()
+			return nil
+		}
+
+		if event.Key() == tcell.KeyRune {
+			switch event.Rune() {
+			case 'j':
+				// pressing j at the end of the list goes to the top
+				if d.list.GetItemCount()-1 == d.list.GetCurrentItem() {
+					d.list.SetCurrentItem(0)
+				} else {
+					d.list.SetCurrentItem(d.list.GetCurrentItem() + 1)
+				}
+				return nil
+			case 'k':
+				d.list.SetCurrentItem(d.list.GetCurrentItem() - 1)
+				return nil
+			}
+		}
+
+		return event
+	})
+}
@@ -0,0 +1,311 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxRestoreErrors is the number of failed statements listed in the summary
+// of a restore
+const maxRestoreErrors = 10
+
+// showDump asks for the tables to dump, starting with the selected table
+func (s *Sidebar) showDump() {
+	var tables []string
+	for _, object := range s.objects {
+		if object.Type == db.ObjectTable {
+			tables = append(tables, object.Name)
+		}
+	}
+
+	if len(tables) == 0 {
+		return
+	}
+
+	var checked []string
+	if object, ok := s.currentObject(); ok && object.Type == db.ObjectTable {
+		checked = append(checked, object.Name)
+	}
+
+	showMultiPicker(s.tab.app, "dump-tables", "Dump tables", tables, checked, func(checked []string) {
+		if len(checked) == 0 {
+			s.tab.app.ShowError("Select at least one table to dump")
+			return
+		}
+
+		s.showDumpForm(checked)
+	})
+}
+
+// showDumpForm asks for the file and the content of the dump
+func (s *Sidebar) showDumpForm(tables []string) {
+	file := s.tab.database + ".sql"
+	if len(tables) == 1 {
+		file = tables[0] + ".sql"
+	}
+
+	form := newModalForm(s.tab.app, "dump", fmt.Sprintf("Dump %d table(s)", len(tables)))
+	form.AddInputField("File", file, 50, nil, nil).
+		AddCheckbox("Structure", true, nil).
+		AddCheckbox("Data", true, nil)
+
+	form.AddButton("Dump", func() {
+		options := db.DumpOptions{
+			Tables:    tables,
+			Structure: formChecked(form, "Structure"),
+			Data:      formChecked(form, "Data"),
+		}
+
+		file := formText(form, "File")
+		if file == "" {
+			s.tab.app.ShowError("File is required")
+			return
+		}
+
+		if !options.Structure && !options.Data {
+			s.tab.app.ShowError("Select the structure, the data or both")
+			return
+		}
+
+		dump := func() {
+			s.tab.app.CloseModal("dump")
+			s.dump(file, options)
+		}
+
+		if _, err := os.Stat(file); err == nil {
+			text := fmt.Sprintf("%s already exists. Overwrite it?", tview.Escape(file))
+			s.tab.app.Confirm("Dump", text, dump)
+			return
+		}
+
+		dump()
+	})
+	form.AddButton("Cancel", func() {
+		s.tab.app.CloseModal("dump")
+	})
+
+	s.tab.app.ShowModal("dump", form, 70, 11)
+}
+
+// dump writes the dump file in the background and shows which table is
+// being dumped
+func (s *Sidebar) dump(file string, options db.DumpOptions) {
+	app := s.tab.app
+
+	progress := tview.NewTextView()
+	progress.SetBorder(true).
+		SetTitle("Dump")
+
+	app.ShowModal("dump-progress", progress, 70, 3)
+
+	go func() {
+		started := time.Now()
+		dumped := 0
+
+		err := writeDump(s.db, file, options, func(table string) {
+			dumped++
+			text := fmt.Sprintf("Dumping %s (%d/%d)", table, dumped, len(options.Tables))
+
+			app.QueueUpdateDraw(func() {
+				progress.SetText(text)
+			})
+		})
+
+		app.QueueUpdateDraw(func() {
+			app.CloseModal("dump-progress")
+
+			if err != nil {
+				app.ShowError(fmt.Sprintf("%v", err))
+				return
+			}
+
+			app.ShowMessage("Dump", fmt.Sprintf(
+				"Dumped %d table(s) to %s in %s",
+				len(options.Tables),
+				tview.Escape(file),
+				time.Since(started).Round(time.Millisecond),
+			))
+		})
+	}()
+}
+
+func writeDump(client *db.DBClient, file string, options db.DumpOptions, onTable func(table string)) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("Failed to create %s: %w", file, err)
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	if err := client.Dump(w, options, onTable); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("Failed to write %s: %w", file, err)
+	}
+
+	return f.Close()
+}
+
+// showRestore asks for a SQL file to run against the current database
+func (s *Sidebar) showRestore() {
+	if s.db.ReadOnly() {
+		s.tab.app.ShowError("Connection is read-only, a dump can't be restored")
+		return
+	}
+
+	form := newModalForm(s.tab.app, "restore", fmt.Sprintf("Restore into %s", s.tab.database))
+	form.AddInputField("File", s.tab.database+".sql", 50, nil, nil).
+		AddCheckbox("Stop on error", true, nil)
+
+	form.AddButton("Restore", func() {
+		file := formText(form, "File")
+		stopOnError := formChecked(form, "Stop on error")
+
+		script, err := os.ReadFile(file)
+		if err != nil {
+			s.tab.app.ShowError(fmt.Sprintf("Failed to read %s: %v", file, err))
+			return
+		}
+
+		statements := db.SplitStatements(string(script))
+		if len(statements) == 0 {
+			s.tab.app.ShowError(fmt.Sprintf("%s has no statements", file))
+			return
+		}
+
+		s.tab.app.CloseModal("restore")
+
+		text := fmt.Sprintf(
+			"Run the %d statements of %s on %s: %s? The script can drop and replace tables.",
+			len(statements),
+			tview.Escape(file),
+			tview.Escape(s.tab.connectionName),
+			tview.Escape(s.tab.database),
+		)
+
+		s.tab.app.ConfirmTyped("Restore", text, s.tab.database, func() {
+			s.restore(statements, stopOnError)
+		})
+	})
+	form.AddButton("Cancel", func() {
+		s.tab.app.CloseModal("restore")
+	})
+
+	s.tab.app.ShowModal("restore", form, 70, 9)
+}
+
+// restore runs the statements in the background with a progress modal, Esc
+// stops after the running statement. The summary lists the failed
+// statements.
+func (s *Sidebar) restore(statements []string, stopOnError bool) {
+	app := s.tab.app
+	ctx, cancel := context.WithCancel(context.Background())
+
+	progress := tview.NewTextView()
+	progress.SetBorder(true).
+		SetTitle("Restore - [Esc] Stop")
+
+	progress.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			cancel()
+			return nil
+		}
+
+		return event
+	})
+
+	app.ShowModal("restore-progress", progress, 70, 3)
+
+	go func() {
+		defer cancel()
+
+		started := time.Now()
+		lastUpdate := time.Time{}
+		ran := 0
+
+		var failed []string
+		var failures int
+
+		err := s.db.ExecScript(ctx, s.tab.database, statements, func(i int, err error) bool {
+			ran++
+
+			if err != nil {
+				failures++
+				if len(failed) < maxRestoreErrors {
+					failed = append(failed, fmt.Sprintf("#%d %s\n  %v", i+1, statementSummary(statements[i]), err))
+				}
+			}
+
+			// don't redraw for every statement of a large dump
+			if time.Since(lastUpdate) > 100*time.Millisecond {
+				lastUpdate = time.Now()
+				text := fmt.Sprintf("Statement %d/%d, %d failed", ran, len(statements), failures)
+
+				app.QueueUpdateDraw(func() {
+					progress.SetText(text)
+				})
+			}
+
+			return err == nil || !stopOnError
+		})
+
+		app.QueueUpdateDraw(func() {
+			app.CloseModal("restore-progress")
+
+			if err := s.Refresh(); err != nil {
+				app.ShowError(fmt.Sprintf("%v", err))
+				return
+			}
+
+			if s.results.selectedTable != "" {
+				s.results.RefreshTable()
+			}
+
+			summary := fmt.Sprintf(
+				"Ran %d of %d statements in %s, %d failed",
+				ran,
+				len(statements),
+				time.Since(started).Round(time.Millisecond),
+				failures,
+			)
+
+			if err != nil {
+				summary += fmt.Sprintf("\nStopped: %v", err)
+			}
+
+			if len(failed) > 0 {
+				summary += "\n\n" + strings.Join(failed, "\n")
+			}
+
+			if err != nil || failures > 0 {
+				app.ShowError(tview.Escape(summary))
+				return
+			}
+
+			app.ShowMessage("Restore", tview.Escape(summary))
+		})
+	}()
+}
+
+// statementSummary shortens a statement to its first line for the summary
+// of a restore
+func statementSummary(statement string) string {
+	line, _, _ := strings.Cut(statement, "\n")
+	if len(line) > 80 || len(line) < len(statement) {
+		return line[:min(len(line), 80)] + "..."
+	}
+
+	return line
+}
@@ -32,22 +32,19 @@ func NewErrorModal() (*ErrorModal, error) {
 }
 
 func (e *ErrorModal) RenderError(errorText string) {
-	/* https://github.com/rivo/tview/wiki/Modal */
-	// Create modal container centered on screen
-	modal := func(p tview.Primitive, width, height int) tview.Primitive {
-		return tview.NewFlex().
-			AddItem(nil, 0, 1, false).
-			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
-				AddItem(nil, 0, 1, false).
-				AddItem(p, height, 1, true).
-				AddItem(nil, 0, 1, false), width, 1, true).
-			AddItem(nil, 0, 1, false)
-	}
+	e.render("ERROR", errorText, tcell.ColorRed)
+}
+
+// RenderMessage shows a non-error message in the same modal
+func (e *ErrorModal) RenderMessage(title string, text string) {
+	e.render(title, text, tcell.ColorWhite)
+}
 
+func (e *ErrorModal) render(title string, errorText string, color tcell.Color) {
 	// Modal text
 	e.errorText = tview.NewTextView().
 		SetText(errorText).
-		SetTextColor(tcell.ColorRed).
+		SetTextColor(color).
 		SetDynamicColors(true)
 
 	// Instructions at the bottom most of the modal
@@ -60,7 +57,7 @@ func (e *ErrorModal) RenderError(errorText string) {
 	// Modal body
 	alertFlex := tview.NewFlex()
 	alertFlex.SetBorder(true).
-		SetTitle("ERROR")
+		SetTitle(title)
 		// SetBorderColor(tcell.ColorRed).
 		// SetTitleColor(tcell.ColorRed)
 	alertFlex.SetDirection(tview.FlexRow)
@@ -69,7 +66,7 @@ func (e *ErrorModal) RenderError(errorText string) {
 	alertFlex.AddItem(legend, 1, 1, false)
 
 	// Modal
-	e.alertModal = modal(alertFlex, 100, 15)
+	e.alertModal = centered(alertFlex, 100, 15)
 	e.alertContainer = alertFlex
 
 	e.setKeyBindings()
@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Explain shows the plan of a query as a tree, or the output of EXPLAIN
+// ANALYZE, on top of the tab
+type Explain struct {
+	app       *App
+	pages     *tview.Pages
+	db        *db.DBClient
+	query     string
+	lastFocus tview.Primitive
+	view      *tview.Flex
+	content   *tview.Pages
+	tree      *tview.TreeView
+	analyze   *tview.TextView
+	analyzed  bool
+}
+
+func NewExplain(app *App, pages *tview.Pages, db *db.DBClient, query string) *Explain {
+	header := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(highlightSQL(query))
+
+	tree := tview.NewTreeView().
+		SetGraphics(true)
+
+	analyze := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+
+	content := tview.NewPages().
+		AddPage("plan", tree, true, true).
+		AddPage("analyze", analyze, true, false)
+
+	legend := tview.NewTextView().
+		SetText("[a] Toggle EXPLAIN ANALYZE (runs the query)  [Esc] Close").
+		SetTextColor(tcell.ColorYellow)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(header, min(strings.Count(query, "\n")+1, 5), 0, false).
+		AddItem(content, 0, 1, true).
+		AddItem(legend, 1, 0, false)
+	view.SetBorder(true)
+
+	explain := &Explain{
+		app:     app,
+		pages:   pages,
+		db:      db,
+		query:   query,
+		view:    view,
+		content: content,
+		tree:    tree,
+		analyze: analyze,
+	}
+
+	explain.setKeyBindings()
+
+	return explain
+}
+
+// Show runs EXPLAIN and shows the plan
+func (e *Explain) Show() error {
+	plan, err := e.db.Explain(e.query)
+	if err != nil {
+		return err
+	}
+
+	root := e.planNode(plan)
+	e.tree.SetRoot(root).
+		SetCurrentNode(root)
+
+	title := "Query plan"
+	if scans := plan.FullScans(); scans > 0 {
+		title = fmt.Sprintf("Query plan [red](%d full table scans)[-]", scans)
+	}
+	e.view.SetTitle(title)
+
+	e.lastFocus = e.app.GetFocus()
+
+	e.pages.RemovePage("explain")
+	e.pages.AddPage("explain", e.view, true, true)
+	e.app.SetFocus(e.tree)
+
+	return nil
+}
+
+func (e *Explain) setKeyBindings() {
+	e.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			e.close()
+			return nil
+		case event.Rune() == 'a':
+			e.toggleAnalyze()
+			return nil
+		}
+
+		return event
+	})
+}
+
+func (e *Explain) close() {
+	e.pages.RemovePage("explain")
+	e.app.SetFocus(e.lastFocus)
+}
+
+// toggleAnalyze switches between the plan and the EXPLAIN ANALYZE output,
+// the query only runs the first time
+func (e *Explain) toggleAnalyze() {
+	if name, _ := e.content.GetFrontPage(); name == "analyze" {
+		e.content.SwitchToPage("plan")
+		e.app.SetFocus(e.tree)
+		return
+	}
+
+	if !e.analyzed {
+		output, err := e.db.ExplainAnalyze(e.query)
+		if err != nil {
+			e.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		e.analyze.SetText(highlightAnalyze(output))
+		e.analyzed = true
+	}
+
+	e.content.SwitchToPage("analyze")
+	e.app.SetFocus(e.analyze)
+}
+
+func (e *Explain) planNode(plan *db.PlanNode) *tview.TreeNode {
+	node := tview.NewTreeNode(planText(plan)).
+		SetSelectable(true)
+
+	if plan.FullScan() {
+		node.SetColor(tcell.ColorRed)
+	}
+
+	if plan.Condition != "" {
+		node.AddChild(tview.NewTreeNode("where " + tview.Escape(plan.Condition)).
+			SetColor(tcell.ColorGray))
+	}
+
+	for _, child := range plan.Children {
+		node.AddChild(e.planNode(child))
+	}
+
+	return node
+}
+
+// planText renders a step of the plan, e.g.
+// table users  type=ALL key=- rows=1200 filtered=10.00  full table scan
+func planText(plan *db.PlanNode) string {
+	var parts []string
+
+	if plan.Table == "" {
+		parts = append(parts, "[yellow]"+tview.Escape(plan.Label)+"[-]")
+	} else {
+		key := plan.Key
+		if key == "" {
+			key = "-"
+		}
+
+		parts = append(parts,
+			"table [lightskyblue]"+tview.Escape(plan.Table)+"[-]",
+			fmt.Sprintf("type=%s key=%s", tview.Escape(plan.Access), tview.Escape(key)),
+		)
+
+		if plan.Rows != "" {
+			parts = append(parts, "rows="+plan.Rows)
+		}
+
+		if plan.Filtered != "" {
+			parts = append(parts, "filtered="+plan.Filtered)
+		}
+	}
+
+	if len(plan.Extra) > 0 {
+		parts = append(parts, "[gray]"+tview.Escape(strings.Join(plan.Extra, ", "))+"[-]")
+	}
+
+	if plan.FullScan() {
+		parts = append(parts, "[white:red] full table scan [-:-]")
+	}
+
+	return strings.Join(parts, "  ")
+}
+
+// highlightAnalyze marks the table scans in the EXPLAIN ANALYZE output
+func highlightAnalyze(output string) string {
+	lines := strings.Split(output, "\n")
+
+	for i, line := range lines {
+		lines[i] = tview.Escape(line)
+
+		if strings.Contains(line, "Table scan") {
+			lines[i] = "[red]" + lines[i] + "[-]"
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
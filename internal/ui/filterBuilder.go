@@ -0,0 +1,353 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// FilterBuilder edits the WHERE filter of Results as rows of column,
+// operator and value, for those less familiar with SQL. Conditions are
+// combined with AND, and groups of them with OR.
+type FilterBuilder struct {
+	app     *App
+	results *Results
+	filter  db.Filter
+	rows    []filterBuilderRow
+	view    *tview.Flex
+	table   *tview.Table
+	preview *tview.TextView
+}
+
+// filterBuilderRow is the position of the condition shown in a row of the
+// table
+type filterBuilderRow struct {
+	group int
+	index int
+}
+
+// showFilterBuilder opens the builder with the current WHERE filter, or an
+// empty one if the filter can't be shown as conditions
+func (r *Results) showFilterBuilder() {
+	if r.selectedTable == "" {
+		return
+	}
+
+	filter, ok := db.ParseFilter(r.filter.GetText())
+	if ok {
+		NewFilterBuilder(r.app, r, filter).Show()
+		return
+	}
+
+	r.app.Confirm(
+		"Filter builder",
+		"The WHERE filter is too complex for the filter builder. Start a new filter? It replaces the WHERE filter once applied.",
+		func() {
+			NewFilterBuilder(r.app, r, db.Filter{}).Show()
+		},
+	)
+}
+
+func NewFilterBuilder(app *App, results *Results, filter db.Filter) *FilterBuilder {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	preview := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true)
+
+	legend := tview.NewTextView().
+		SetText("[a] AND condition  [o] OR group  [Enter] Edit  [d] Delete  [s] Apply  [Esc] Cancel").
+		SetTextColor(tcell.ColorYellow)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(preview, 3, 0, false).
+		AddItem(legend, 1, 0, false)
+	view.SetBorder(true).
+		SetTitle(tview.Escape(fmt.Sprintf("Filter %s", results.selectedTable)))
+
+	builder := &FilterBuilder{
+		app:     app,
+		results: results,
+		filter:  filter,
+		view:    view,
+		table:   table,
+		preview: preview,
+	}
+
+	builder.setKeyBindings()
+	builder.render()
+
+	return builder
+}
+
+func (b *FilterBuilder) Show() {
+	b.app.ShowModal("filter-builder", b.view, 100, 20)
+	b.app.SetFocus(b.table)
+
+	// start with a condition instead of an empty list
+	if len(b.rows) == 0 {
+		b.addCondition(0)
+	}
+}
+
+func (b *FilterBuilder) setKeyBindings() {
+	b.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			b.app.CloseModal("filter-builder")
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			if row, ok := b.selectedRow(); ok {
+				b.editCondition(row.group, row.index)
+			}
+			return nil
+		case event.Rune() == 'a':
+			group := 0
+			if row, ok := b.selectedRow(); ok {
+				group = row.group
+			}
+			b.addCondition(group)
+			return nil
+		case event.Rune() == 'o':
+			b.addCondition(len(b.filter.Groups))
+			return nil
+		case event.Rune() == 'd':
+			b.deleteCondition()
+			return nil
+		case event.Rune() == 's':
+			b.apply()
+			return nil
+		}
+
+		return event
+	})
+}
+
+// render lists the conditions with AND or OR in front of them, and shows
+// the WHERE filter they make
+func (b *FilterBuilder) render() {
+	b.table.Clear()
+	b.rows = nil
+
+	for i, header := range []string{"", "Column", "Operator", "Value"} {
+		b.table.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, group := range b.filter.Groups {
+		for j, condition := range group {
+			connector := ""
+			switch {
+			case j > 0:
+				connector = "AND"
+			case i > 0:
+				connector = "OR"
+			}
+
+			b.rows = append(b.rows, filterBuilderRow{group: i, index: j})
+			row := len(b.rows)
+
+			b.table.SetCell(row, 0, tview.NewTableCell(connector).SetTextColor(tcell.ColorGray))
+			b.table.SetCell(row, 1, tview.NewTableCell(tview.Escape(condition.Column)))
+			b.table.SetCell(row, 2, tview.NewTableCell(operatorLabel(condition.Operator)))
+			b.table.SetCell(row, 3, tview.NewTableCell(tview.Escape(condition.Value)).SetMaxWidth(50))
+		}
+	}
+
+	where := b.filter.SQL()
+	if where == "" {
+		b.preview.SetText("[gray]WHERE (none)[-]")
+	} else {
+		b.preview.SetText(highlightSQL("WHERE " + where))
+	}
+}
+
+func (b *FilterBuilder) selectedRow() (filterBuilderRow, bool) {
+	row, _ := b.table.GetSelection()
+	if row < 1 || row > len(b.rows) {
+		return filterBuilderRow{}, false
+	}
+
+	return b.rows[row-1], true
+}
+
+// addCondition asks for a condition to add to the group, a new group if
+// it doesn't exist yet
+func (b *FilterBuilder) addCondition(group int) {
+	if len(b.results.dbColumns) == 0 {
+		return
+	}
+
+	column := b.results.dbColumns[0]
+	if _, col := b.results.resultsTable.GetSelection(); col < len(b.results.columns) {
+		column = b.results.columns[col]
+	}
+
+	condition := db.FilterCondition{
+		Column:   column.Name,
+		Operator: db.FilterOperators(column)[0],
+	}
+
+	b.showConditionForm("Add condition", condition, func(condition db.FilterCondition) {
+		if group >= len(b.filter.Groups) {
+			b.filter.Groups = append(b.filter.Groups, nil)
+		}
+
+		b.filter.Groups[group] = append(b.filter.Groups[group], condition)
+		b.render()
+		b.selectCondition(group, len(b.filter.Groups[group])-1)
+	})
+}
+
+func (b *FilterBuilder) editCondition(group int, index int) {
+	b.showConditionForm("Edit condition", b.filter.Groups[group][index], func(condition db.FilterCondition) {
+		b.filter.Groups[group][index] = condition
+		b.render()
+		b.selectCondition(group, index)
+	})
+}
+
+// deleteCondition deletes the selected condition, and its group if it was
+// the last condition of it
+func (b *FilterBuilder) deleteCondition() {
+	row, ok := b.selectedRow()
+	if !ok {
+		return
+	}
+
+	group := slices.Delete(b.filter.Groups[row.group], row.index, row.index+1)
+	if len(group) == 0 {
+		b.filter.Groups = slices.Delete(b.filter.Groups, row.group, row.group+1)
+	} else {
+		b.filter.Groups[row.group] = group
+	}
+
+	selected, _ := b.table.GetSelection()
+	b.render()
+	b.table.Select(max(1, min(selected, len(b.rows))), 0)
+}
+
+func (b *FilterBuilder) selectCondition(group int, index int) {
+	if i := slices.Index(b.rows, filterBuilderRow{group, index}); i >= 0 {
+		b.table.Select(i+1, 0)
+	}
+}
+
+// apply sets the WHERE filter of Results and renders the table with it
+func (b *FilterBuilder) apply() {
+	where := b.filter.SQL()
+
+	if err := b.results.RenderTable(b.results.selectedTable, where); err != nil {
+		b.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	b.results.filter.SetText(where)
+	b.app.CloseModal("filter-builder")
+	b.app.SetFocus(b.results.resultsTable)
+}
+
+// showConditionForm asks for the column, the operator and the value of a
+// condition. The operators depend on the type of the column.
+func (b *FilterBuilder) showConditionForm(
+	title string,
+	condition db.FilterCondition,
+	onSubmit func(condition db.FilterCondition),
+) {
+	columns := b.results.dbColumns
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+
+	form := newModalForm(b.app, "filter-condition", title)
+
+	var operators []db.FilterOperator
+	operatorDropDown := tview.NewDropDown().SetLabel("Operator")
+
+	// the operators of the selected column, keeping the selected operator
+	// even if it doesn't fit the column, e.g. LIKE on a date in a typed
+	// filter
+	setOperators := func(column db.Column) {
+		current := condition.Operator
+		if _, option := operatorDropDown.GetCurrentOption(); option != "" {
+			current = operators[slices.Index(operatorLabels(operators), option)]
+		}
+
+		operators = db.FilterOperators(column)
+		if current != "" && !slices.Contains(operators, current) {
+			operators = append(operators, current)
+		}
+
+		operatorDropDown.SetOptions(operatorLabels(operators), nil)
+		operatorDropDown.SetCurrentOption(max(0, slices.Index(operators, current)))
+	}
+
+	columnIndex := max(0, slices.Index(names, condition.Column))
+
+	form.AddDropDown("Column", names, columnIndex, func(option string, index int) {
+		if index >= 0 {
+			setOperators(columns[index])
+		}
+	})
+	setOperators(columns[columnIndex])
+
+	form.AddFormItem(operatorDropDown).
+		AddInputField("Value", condition.Value, 40, nil, nil)
+
+	form.AddButton("OK", func() {
+		columnIndex, _ := form.GetFormItemByLabel("Column").(*tview.DropDown).GetCurrentOption()
+		operatorIndex, _ := operatorDropDown.GetCurrentOption()
+
+		condition := db.FilterCondition{
+			Column:   columns[columnIndex].Name,
+			Operator: operators[operatorIndex],
+			Value:    formText(form, "Value"),
+		}
+
+		switch condition.Operator {
+		case db.FilterIsNull, db.FilterIsNotNull:
+			condition.Value = ""
+		default:
+			if condition.Value == "" && condition.Operator != db.FilterEquals && condition.Operator != db.FilterNotEquals {
+				b.app.ShowError("Value is required")
+				return
+			}
+		}
+
+		b.app.CloseModal("filter-condition")
+		onSubmit(condition)
+	})
+	form.AddButton("Cancel", func() {
+		b.app.CloseModal("filter-condition")
+	})
+
+	b.app.ShowModal("filter-condition", form, 60, 11)
+}
+
+// operatorLabel shows LIKE as contains, since the value is not a pattern
+func operatorLabel(operator db.FilterOperator) string {
+	if operator == db.FilterContains {
+		return "contains"
+	}
+
+	return string(operator)
+}
+
+func operatorLabels(operators []db.FilterOperator) []string {
+	labels := make([]string, len(operators))
+	for i, operator := range operators {
+		labels[i] = operatorLabel(operator)
+	}
+
+	return labels
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	keywords := `ADD AFTER ALGORITHM ALL ALTER AND AS ASC AUTO_INCREMENT BEFORE BEGIN
+		BETWEEN BY CASCADE CASE CHARACTER CHARSET CHECK COLLATE COLUMN COMMENT
+		CONSTRAINT CREATE CURRENT_TIMESTAMP DECLARE DEFAULT DEFINER DELETE DESC
+		DETERMINISTIC DISTINCT DO DROP EACH ELSE ELSEIF END ENGINE EVENT EVERY
+		EXISTS FOR FOREIGN FROM FULLTEXT FUNCTION GROUP HAVING IF IN INDEX INNER
+		INSERT INTO IS JOIN KEY LEFT LIKE LIMIT MODIFY NOT NULL ON OR ORDER
+		PRIMARY PROCEDURE REFERENCES RENAME REPLACE RETURN RETURNS RIGHT ROW
+		SCHEDULE SECURITY SELECT SET SQL TABLE TEMPORARY THEN TO TRIGGER UNION
+		UNIQUE UNSIGNED UPDATE USING VALUES VIEW WHEN WHERE WHILE WITH ZEROFILL`
+
+	for _, keyword := range strings.Fields(keywords) {
+		sqlKeywords[keyword] = true
+	}
+}
+
+// sqlToken matches, in order: comments, quoted strings, backtick quoted
+// identifiers, numbers and words
+var sqlToken = regexp.MustCompile(
+	"(?s)(--[^\\n]*|#[^\\n]*|/\\*.*?\\*/)" +
+		`|('(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*")` +
+		"|(`(?:[^`]|``)*`)" +
+		`|(\b\d+(?:\.\d+)?\b)` +
+		`|(\b[A-Za-z_]+\b)`,
+)
+
+// highlightSQL adds tview color tags to a SQL statement for display in a
+// TextView with dynamic colors. The statement itself is escaped.
+func highlightSQL(statement string) string {
+	var sb strings.Builder
+
+	last := 0
+
+	for _, match := range sqlToken.FindAllStringSubmatchIndex(statement, -1) {
+		sb.WriteString(tview.Escape(statement[last:match[0]]))
+
+		token := statement[match[0]:match[1]]
+		color := ""
+
+		switch {
+		case match[2] != -1:
+			color = "gray"
+		case match[4] != -1:
+			color = "green"
+		case match[6] != -1:
+			color = "lightskyblue"
+		case match[8] != -1:
+			color = "fuchsia"
+		case sqlKeywords[strings.ToUpper(token)]:
+			color = "yellow"
+		}
+
+		if color == "" {
+			sb.WriteString(tview.Escape(token))
+		} else {
+			sb.WriteString("[" + color + "]" + tview.Escape(token) + "[-]")
+		}
+
+		last = match[1]
+	}
+
+	sb.WriteString(tview.Escape(statement[last:]))
+
+	return sb.String()
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// centered wraps the primitive in a container that centers it on screen
+// with the given size, see https://github.com/rivo/tview/wiki/Modal
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// newModalForm returns a bordered form to show with app.ShowModal. Esc
+// closes the modal with the given name.
+func newModalForm(app *App, name string, title string) *tview.Form {
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(title)
+	form.SetFieldBackgroundColor(tcell.Color237)
+	form.SetCancelFunc(func() {
+		app.CloseModal(name)
+	})
+
+	return form
+}
@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alfonzm/lazydb/internal/config"
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// pickConnection asks for a connection, e.g. to compare with the tab's
+// database. The connection comes from the shared pools and is released if
+// onPick fails, otherwise onPick owns it.
+func pickConnection(t *Tab, title string, onPick func(connectionName string, client *db.DBClient) error) {
+	connections, err := config.GetConnections()
+	if err != nil {
+		t.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	names := make([]string, 0, len(connections))
+	for name := range connections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	showPicker(t.app, "pick-connection", title, names, t.connectionName, func(name string) {
+		connection := connections[name]
+
+		// same database name on the other server by default
+		if connection.Database == "" {
+			connection.Database = t.database
+		}
+
+		client, err := t.app.pools.Acquire(name, connection)
+		if err != nil {
+			t.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		if err := onPick(name, client); err != nil {
+			t.app.pools.Release(client)
+			t.app.ShowError(fmt.Sprintf("%v", err))
+		}
+	}, nil)
+}
+
+// pickTable asks for a connection and a table, e.g. to compare with the
+// tab's table. The connection is released if onPick fails or nothing is
+// picked, otherwise onPick owns it.
+func pickTable(t *Tab, title string, onPick func(connectionName string, client *db.DBClient, table string) error) {
+	pickConnection(t, title, func(name string, client *db.DBClient) error {
+		tables, err := client.GetTables()
+		if err != nil {
+			return err
+		}
+
+		selected := ""
+		if t.results != nil {
+			selected = t.results.selectedTable
+		}
+
+		showPicker(t.app, "pick-table", fmt.Sprintf("%s (%s)", title, name), tables, selected, func(table string) {
+			if err := onPick(name, client, table); err != nil {
+				t.app.pools.Release(client)
+				t.app.ShowError(fmt.Sprintf("%v", err))
+			}
+		}, func() {
+			t.app.pools.Release(client)
+		})
+
+		return nil
+	})
+}
+
+// showPicker shows a list of items to choose from, j/k move and Esc closes
+// it. onCancel is optional.
+func showPicker(
+	app *App,
+	name string,
+	title string,
+	items []string,
+	selected string,
+	onSelect func(item string),
+	onCancel func(),
+) {
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	list.SetBorder(true).
+		SetTitle(title)
+
+	for i, item := range items {
+		list.AddItem(item, "", 0, func() {
+			app.CloseModal(name)
+			onSelect(item)
+		})
+
+		if item == selected {
+			list.SetCurrentItem(i)
+		}
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			app.CloseModal(name)
+			if onCancel != nil {
+				onCancel()
+			}
+			return nil
+		case event.Rune() == 'j':
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case event.Rune() == 'k':
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		}
+
+		return event
+	})
+
+	app.ShowModal(name, list, 50, min(len(items)+2, 20))
+}
+
+// showMultiPicker shows a list of items to check, Space toggles an item, a
+// toggles all of them and Enter calls onDone with the checked items
+func showMultiPicker(
+	app *App,
+	name string,
+	title string,
+	items []string,
+	checked []string,
+	onDone func(checked []string),
+) {
+	isChecked := make([]bool, len(items))
+	for i, item := range items {
+		isChecked[i] = slices.Contains(checked, item)
+	}
+
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	list.SetBorder(true).
+		SetTitle(tview.Escape(title + " - [Space] Toggle, [a] All, [Enter] Done"))
+
+	itemText := func(i int) string {
+		if isChecked[i] {
+			return tview.Escape("[x] " + items[i])
+		}
+
+		return tview.Escape("[ ] " + items[i])
+	}
+
+	for i := range items {
+		list.AddItem(itemText(i), "", 0, nil)
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			app.CloseModal(name)
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			var result []string
+			for i, item := range items {
+				if isChecked[i] {
+					result = append(result, item)
+				}
+			}
+
+			app.CloseModal(name)
+			onDone(result)
+			return nil
+		case event.Rune() == ' ':
+			i := list.GetCurrentItem()
+			if i >= 0 && i < len(items) {
+				isChecked[i] = !isChecked[i]
+				list.SetItemText(i, itemText(i), "")
+			}
+			return nil
+		case event.Rune() == 'a':
+			// check all unless all are checked already
+			all := !slices.Contains(isChecked, false)
+			for i := range items {
+				isChecked[i] = !all
+				list.SetItemText(i, itemText(i), "")
+			}
+			return nil
+		case event.Rune() == 'j':
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case event.Rune() == 'k':
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		}
+
+		return event
+	})
+
+	app.ShowModal(name, list, 70, min(len(items)+2, 20))
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/config"
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/rivo/tview"
+)
+
+// Pools shares one connection pool per connection and database between the
+// tabs, instead of opening a pool for every tab. A pool is closed when the
+// last tab using it releases it.
+type Pools struct {
+	pools map[poolKey]*pool
+}
+
+// poolKey identifies a pool by the connection name and the DSN, so editing
+// a connection in the config opens a new pool instead of reusing the old
+// settings
+type poolKey struct {
+	name     string
+	dsn      string
+	readOnly bool
+}
+
+type pool struct {
+	client   *db.DBClient
+	name     string
+	database string
+	refs     int
+}
+
+func NewPools() *Pools {
+	return &Pools{pools: map[poolKey]*pool{}}
+}
+
+// Acquire returns the client of the connection, opening its pool if no tab
+// uses it yet. Call Release when the tab doesn't use it anymore.
+func (p *Pools) Acquire(name string, connection config.Connection) (*db.DBClient, error) {
+	key := poolKey{name: name, dsn: connection.String(), readOnly: connection.ReadOnly}
+
+	if existing, ok := p.pools[key]; ok {
+		existing.refs++
+		return existing.client, nil
+	}
+
+	client, err := db.NewDBClient(key.dsn, key.readOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	p.pools[key] = &pool{
+		client:   client,
+		name:     name,
+		database: connection.Database,
+		refs:     1,
+	}
+
+	return client, nil
+}
+
+// Release closes the pool of the client once no tab uses it
+func (p *Pools) Release(client *db.DBClient) {
+	for key, existing := range p.pools {
+		if existing.client != client {
+			continue
+		}
+
+		existing.refs--
+		if existing.refs <= 0 {
+			existing.client.Close()
+			delete(p.pools, key)
+		}
+
+		return
+	}
+}
+
+// Stats describes the open pools, e.g. to find out why a server runs out
+// of connections
+func (p *Pools) Stats() string {
+	pools := make([]*pool, 0, len(p.pools))
+	for _, existing := range p.pools {
+		pools = append(pools, existing)
+	}
+
+	slices.SortFunc(pools, func(a, b *pool) int {
+		return cmp.Or(cmp.Compare(a.name, b.name), cmp.Compare(a.database, b.database))
+	})
+
+	if len(pools) == 0 {
+		return "No open connections"
+	}
+
+	var sb strings.Builder
+
+	for _, existing := range pools {
+		stats := existing.client.Stats()
+
+		database := existing.database
+		if database == "" {
+			database = "no database"
+		}
+
+		fmt.Fprintf(
+			&sb,
+			"[yellow]%s[-] (%s), %d reference(s)\n  %d open: %d in use, %d idle, max %d\n  waited %d times for %s\n\n",
+			tview.Escape(existing.name),
+			tview.Escape(database),
+			existing.refs,
+			stats.OpenConnections,
+			stats.InUse,
+			stats.Idle,
+			stats.MaxOpenConnections,
+			stats.WaitCount,
+			stats.WaitDuration,
+		)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n\n")
+}
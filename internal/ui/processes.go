@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const processesRefreshInterval = 2 * time.Second
+
+// Processes lists the connections to the server, to find and kill
+// runaway queries
+type Processes struct {
+	app         *App
+	db          *db.DBClient
+	view        *tview.Flex
+	filter      *tview.InputField
+	table       *tview.Table
+	processes   []db.Process
+	sortByTime  bool
+	autoRefresh bool
+	refresher   *autoRefresh
+}
+
+func NewProcesses(app *App, db *db.DBClient) (*Processes, error) {
+	filter := tview.NewInputField().
+		SetLabel("Filter ").
+		SetPlaceholder("user, db, command or state").
+		SetFieldBackgroundColor(tcell.ColorNone)
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	legend := tview.NewTextView().
+		SetText("[Enter] Full query  [x] Kill query  [X] Kill connection  [s] Sort by time  [a] Auto refresh  [r] Refresh  [/] Filter").
+		SetTextColor(tcell.ColorYellow)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, false).
+		AddItem(table, 0, 1, true).
+		AddItem(legend, 1, 0, false)
+	view.SetBorder(true)
+
+	processes := &Processes{
+		app:         app,
+		db:          db,
+		view:        view,
+		filter:      filter,
+		table:       table,
+		sortByTime:  true,
+		autoRefresh: true,
+	}
+
+	processes.refresher = newAutoRefresh(app, processesRefreshInterval, processes.load)
+	processes.setKeyBindings()
+
+	return processes, nil
+}
+
+func (p *Processes) setKeyBindings() {
+	p.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEnter {
+			p.showQuery()
+			return nil
+		}
+
+		if event.Key() == tcell.KeyEscape && p.filter.GetText() != "" {
+			p.filter.SetText("")
+			return nil
+		}
+
+		switch event.Rune() {
+		case '/':
+			p.app.SetFocus(p.filter)
+		case 'x':
+			p.kill(true)
+		case 'X':
+			p.kill(false)
+		case 's':
+			p.sortByTime = !p.sortByTime
+			p.render()
+		case 'a':
+			p.autoRefresh = !p.autoRefresh
+			if p.autoRefresh {
+				p.refresher.Start()
+			} else {
+				p.refresher.Stop()
+			}
+			p.render()
+		case 'r':
+			p.Refresh()
+		default:
+			return event
+		}
+
+		return nil
+	})
+
+	p.filter.SetChangedFunc(func(text string) {
+		p.render()
+	})
+
+	p.filter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			p.filter.SetText("")
+		}
+
+		p.app.SetFocus(p.table)
+	})
+}
+
+func (p *Processes) SetBorderColor(color tcell.Color) {
+	p.view.SetBorderColor(color)
+}
+
+// Start loads the process list and refreshes it until Stop is called
+func (p *Processes) Start() {
+	p.Refresh()
+
+	if p.autoRefresh {
+		p.refresher.Start()
+	}
+}
+
+func (p *Processes) Stop() {
+	p.refresher.Stop()
+}
+
+// Refresh loads the process list in the background
+func (p *Processes) Refresh() {
+	loadInBackground(p.app, p.load)
+}
+
+// load gets the process list, the returned function renders it
+func (p *Processes) load() func() {
+	processes, err := p.db.GetProcesses()
+
+	return func() {
+		if err != nil {
+			// don't keep showing the same error on every refresh
+			p.autoRefresh = false
+			p.refresher.Stop()
+			p.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		p.processes = processes
+		p.render()
+	}
+}
+
+func (p *Processes) render() {
+	selected := p.selectedProcess()
+
+	p.table.Clear()
+
+	headers := []string{"Id", "User", "Host", "Db", "Command", "Time", "State", "Query"}
+	for i, header := range headers {
+		if header == "Time" && p.sortByTime {
+			header = "Time ↓"
+		}
+
+		p.table.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	processes := p.filtered()
+	if p.sortByTime {
+		slices.SortStableFunc(processes, func(a, b db.Process) int {
+			return cmp.Compare(b.Time, a.Time)
+		})
+	}
+
+	for i, process := range processes {
+		query := strings.Join(strings.Fields(process.Query), " ")
+
+		values := []string{
+			strconv.FormatInt(process.ID, 10),
+			process.User,
+			process.Host,
+			process.DB,
+			process.Command,
+			strconv.FormatInt(process.Time, 10),
+			process.State,
+			query,
+		}
+
+		for j, value := range values {
+			cell := tview.NewTableCell(tview.Escape(value)).
+				SetReference(process).
+				SetMaxWidth(40)
+
+			if j == len(values)-1 {
+				cell.SetMaxWidth(0).SetExpansion(1)
+			}
+
+			// Highlight long running queries
+			if process.Query != "" && process.Time >= 10 {
+				cell.SetTextColor(tcell.ColorRed)
+			}
+
+			p.table.SetCell(i+1, j, cell)
+		}
+
+		if selected != nil && process.ID == selected.ID {
+			p.table.Select(i+1, 0)
+		}
+	}
+
+	refresh := "off"
+	if p.autoRefresh {
+		refresh = fmt.Sprintf("every %s", processesRefreshInterval)
+	}
+
+	p.view.SetTitle(fmt.Sprintf("Processes (%d) - auto refresh %s", len(processes), refresh))
+}
+
+// filtered returns the processes whose user, db, command or state contains
+// the filter text
+func (p *Processes) filtered() []db.Process {
+	filter := strings.ToLower(strings.TrimSpace(p.filter.GetText()))
+
+	var processes []db.Process
+
+	for _, process := range p.processes {
+		text := strings.ToLower(strings.Join(
+			[]string{process.User, process.DB, process.Command, process.State},
+			" ",
+		))
+
+		if filter == "" || strings.Contains(text, filter) {
+			processes = append(processes, process)
+		}
+	}
+
+	return processes
+}
+
+func (p *Processes) selectedProcess() *db.Process {
+	row, _ := p.table.GetSelection()
+	if row < 1 || row >= p.table.GetRowCount() {
+		return nil
+	}
+
+	process, ok := p.table.GetCell(row, 0).GetReference().(db.Process)
+	if !ok {
+		return nil
+	}
+
+	return &process
+}
+
+// showQuery shows the full query of the selected process
+func (p *Processes) showQuery() {
+	process := p.selectedProcess()
+	if process == nil || process.Query == "" {
+		return
+	}
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(highlightSQL(process.Query))
+	textView.SetBorder(true).
+		SetTitle(fmt.Sprintf("Process %d (%s@%s)", process.ID, process.User, process.Host))
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyEnter {
+			p.app.CloseModal("process-query")
+			return nil
+		}
+
+		return event
+	})
+
+	p.app.ShowModal("process-query", textView, 100, modalHeight(process.Query, 0))
+}
+
+// kill stops the query of the selected process or closes its connection,
+// after confirmation
+func (p *Processes) kill(queryOnly bool) {
+	process := p.selectedProcess()
+	if process == nil {
+		return
+	}
+
+	if p.db.ReadOnly() {
+		p.app.ShowError("Connection is read-only, processes can't be killed")
+		return
+	}
+
+	statement := db.KillSQL(process.ID, queryOnly)
+
+	text := fmt.Sprintf(
+		"%s\n\n[gray]%s@%s %s, running for %ds\n%s",
+		highlightSQL(statement),
+		tview.Escape(process.User),
+		tview.Escape(process.Host),
+		tview.Escape(process.Command),
+		process.Time,
+		tview.Escape(process.Query),
+	)
+
+	p.app.Confirm(fmt.Sprintf("Kill process %d", process.ID), text, func() {
+		if err := p.db.Exec(statement); err != nil {
+			p.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		p.Refresh()
+	})
+}
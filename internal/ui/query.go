@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/alfonzm/lazydb/internal/db"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -8,6 +11,7 @@ import (
 
 type Query struct {
 	app      *App
+	pages    *tview.Pages
 	db       *db.DBClient
 	textArea *tview.TextArea
 	table    *tview.Table
@@ -16,6 +20,7 @@ type Query struct {
 
 func NewQuery(
 	app *App,
+	pages *tview.Pages,
 	db *db.DBClient,
 ) (*Query, error) {
 	view := tview.NewFlex()
@@ -35,6 +40,7 @@ func NewQuery(
 
 	query := &Query{
 		app:      app,
+		pages:    pages,
 		db:       db,
 		table:    table,
 		textArea: textArea,
@@ -53,6 +59,12 @@ func (q *Query) setKeyBindings() {
 			// TODO: Run the query
 		}
 
+		// ctrl+E to show the query plan
+		if event.Key() == tcell.KeyCtrlE {
+			q.Explain()
+			return nil
+		}
+
 		// escape to clear the text area
 		if event.Key() == tcell.KeyEscape {
 			q.textArea.SetText("", false)
@@ -61,3 +73,20 @@ func (q *Query) setKeyBindings() {
 		return event
 	})
 }
+
+func (q *Query) SetBorderColor(color tcell.Color) {
+	q.textArea.SetBorderColor(color)
+	q.table.SetBorderColor(color)
+}
+
+// Explain shows the plan of the statement in the editor
+func (q *Query) Explain() {
+	statement := strings.TrimSuffix(strings.TrimSpace(q.textArea.GetText()), ";")
+	if statement == "" {
+		return
+	}
+
+	if err := NewExplain(q.app, q.pages, q.db, statement).Show(); err != nil {
+		q.app.ShowError(fmt.Sprintf("%v", err))
+	}
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// markedCell is a cell of the results table marked with m, its values are
+// used by the IN quick filter
+type markedCell struct {
+	row int
+	col int
+}
+
+// toggleMarkCell marks or unmarks the selected cell
+func (r *Results) toggleMarkCell() {
+	row, col := r.resultsTable.GetSelection()
+	if row == 0 || col >= len(r.columns) {
+		return
+	}
+
+	cell := r.resultsTable.GetCell(row, col)
+
+	if i := slices.Index(r.markedCells, markedCell{row, col}); i >= 0 {
+		r.markedCells = slices.Delete(r.markedCells, i, i+1)
+		cell.SetBackgroundColor(tcell.ColorDefault)
+		return
+	}
+
+	r.markedCells = append(r.markedCells, markedCell{row, col})
+	cell.SetBackgroundColor(tcell.ColorDarkCyan)
+}
+
+// showQuickFilters shows the filters on the value of the selected cell.
+// The chosen filter is added to the WHERE filter with AND.
+func (r *Results) showQuickFilters() {
+	row, col := r.resultsTable.GetSelection()
+	if col >= len(r.columns) {
+		return
+	}
+
+	// no value to filter by on the header
+	if row == 0 || row > len(r.records) {
+		r.filterCurrentColumn()
+		return
+	}
+
+	column := r.columns[col].Name
+	value := r.records[row-1][column]
+	values := r.markedValues(row, col)
+
+	label := "NULL"
+	if value != nil {
+		label = fmt.Sprintf("%v", value)
+	}
+
+	if len([]rune(label)) > 30 {
+		label = string([]rune(label)[:30]) + "..."
+	}
+
+	apply := func(operator db.FilterOperator, values []any) func() {
+		return func() {
+			r.app.CloseModal("quick-filters")
+			r.addFilter(db.FilterSQL(column, operator, values))
+		}
+	}
+
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		AddItem(tview.Escape(fmt.Sprintf("= %s", label)), "", '=', apply(db.FilterEquals, []any{value})).
+		AddItem(tview.Escape(fmt.Sprintf("!= %s", label)), "", '!', apply(db.FilterNotEquals, []any{value})).
+		AddItem("IS NULL", "", 'n', apply(db.FilterIsNull, nil)).
+		AddItem("IS NOT NULL", "", 'N', apply(db.FilterIsNotNull, nil))
+
+	// comparing with NULL never matches
+	if value != nil {
+		list.AddItem(tview.Escape(fmt.Sprintf("Contains %s", label)), "", 'l', apply(db.FilterContains, []any{value})).
+			AddItem(tview.Escape(fmt.Sprintf("> %s", label)), "", '>', apply(db.FilterGreaterThan, []any{value})).
+			AddItem(tview.Escape(fmt.Sprintf("< %s", label)), "", '<', apply(db.FilterLessThan, []any{value}))
+	}
+
+	list.AddItem(fmt.Sprintf("IN marked cells (%d values)", len(values)), "", 'i', apply(db.FilterIn, values)).
+		AddItem("Type a condition", "", 'w', func() {
+			r.app.CloseModal("quick-filters")
+			r.filterCurrentColumn()
+		})
+	list.SetBorder(true).
+		SetTitle(tview.Escape(fmt.Sprintf("Filter %s", column)))
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			r.app.CloseModal("quick-filters")
+			return nil
+		case event.Rune() == 'j':
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case event.Rune() == 'k':
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		}
+
+		return event
+	})
+
+	r.app.ShowModal("quick-filters", list, 50, list.GetItemCount()+2)
+}
+
+// markedValues returns the values of the marked cells of the column and of
+// the selected cell, for the IN filter
+func (r *Results) markedValues(row int, col int) []any {
+	values := []any{r.records[row-1][r.columns[col].Name]}
+
+	for _, cell := range r.markedCells {
+		if cell.col == col && cell.row != row && cell.row <= len(r.records) {
+			values = append(values, r.records[cell.row-1][r.columns[col].Name])
+		}
+	}
+
+	return values
+}
+
+// addFilter adds the condition to the WHERE filter and renders the results
+func (r *Results) addFilter(condition string) {
+	_, col := r.resultsTable.GetSelection()
+	where := db.AndWhere(r.filter.GetText(), condition)
+
+	if err := r.RenderTable(r.selectedTable, where); err != nil {
+		r.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	r.filter.SetText(where)
+	r.app.SetFocus(r.resultsTable)
+
+	// stay in the column to add another filter on it
+	r.resultsTable.Select(min(1, len(r.records)), col)
+}
@@ -3,9 +3,11 @@ package ui
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/alfonzm/lazydb/internal/config"
 	"github.com/alfonzm/lazydb/internal/db"
 	"github.com/atotto/clipboard"
 	"github.com/gdamore/tcell/v2"
@@ -22,14 +24,23 @@ type Results struct {
 	pages                *tview.Pages
 	db                   *db.DBClient
 	view                 *tview.Pages
+	resultsPage          *tview.Flex
 	resultsTable         *tview.Table
 	structure            *Structure
 	filter               *tview.InputField
 	cellEditor           *CellEditor
 	query                *Query
+	processes            *Processes
+	status               *Status
+	users                *Users
 	selectedTable        string
-	sortColumn           SortColumn
+	sortColumns          []SortColumn
+	tableSorts           map[string][]SortColumn
 	dbColumns            []db.Column
+	columns              []db.Column
+	hiddenColumns        []string
+	records              []map[string]interface{}
+	markedCells          []markedCell
 	selectedRowForDelete int
 }
 
@@ -58,7 +69,25 @@ func NewResults(app *App, pages *tview.Pages, db *db.DBClient) (*Results, error)
 	}
 
 	// Setup SQL Editor page
-	queryEditor, err := NewQuery(app, db)
+	queryEditor, err := NewQuery(app, pages, db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup Processes page
+	processes, err := NewProcesses(app, db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup Status page
+	status, err := NewStatus(app, db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup Users page
+	users, err := NewUsers(app, db)
 	if err != nil {
 		return nil, err
 	}
@@ -67,16 +96,24 @@ func NewResults(app *App, pages *tview.Pages, db *db.DBClient) (*Results, error)
 	view.AddPage("results", resultsPage, true, true)
 	view.AddPage("columns", structure.view, true, false)
 	view.AddPage("query", queryEditor.view, true, false)
+	view.AddPage("processes", processes.view, true, false)
+	view.AddPage("status", status.view, true, false)
+	view.AddPage("users", users.view, true, false)
 
 	results := &Results{
 		app:          app,
 		resultsTable: resultsTable,
+		resultsPage:  resultsPage,
 		structure:    structure,
 		view:         view,
 		db:           db,
 		query:        queryEditor,
+		processes:    processes,
+		status:       status,
+		users:        users,
 		filter:       filter,
 		pages:        pages,
+		tableSorts:   map[string][]SortColumn{},
 	}
 
 	results.renderFilterField()
@@ -100,32 +137,50 @@ func (r *Results) RenderTable(table string, where string) error {
 
 	r.dbColumns = dbColumns
 
-	orderBy := ""
-	if r.sortColumn.Name != "" {
-		orderBy = r.sortColumn.Name
-		if !r.sortColumn.Ascending {
-			orderBy = fmt.Sprintf("%s DESC", orderBy)
-		}
+	// forget the sort on columns that were dropped or renamed since
+	sortColumns := slices.DeleteFunc(slices.Clone(r.sortColumns), func(s SortColumn) bool {
+		return !slices.ContainsFunc(dbColumns, func(c db.Column) bool { return c.Name == s.Name })
+	})
+	if len(sortColumns) < len(r.sortColumns) {
+		r.sortColumns = sortColumns
+		r.rememberSort()
 	}
 
-	dbRecords, err := r.db.GetRecords(table, where, orderBy)
+	dbRecords, err := r.db.GetRecords(table, where, r.orderBy())
 	if err != nil {
 		return err
 	}
 
+	r.records = dbRecords
+
+	// the visible columns, the indexes of the table cells match this slice
+	r.columns = nil
+	for _, column := range dbColumns {
+		if !slices.Contains(r.hiddenColumns, column.Name) {
+			r.columns = append(r.columns, column)
+		}
+	}
+
 	r.resultsTable.Clear()
+	r.markedCells = nil
 
 	// set headers from columns
-	for i, column := range dbColumns {
+	for i, column := range r.columns {
 		var columnName string = column.Name
 
-		// append sort arrow to column name
-		if r.sortColumn.Name == column.Name {
-			if r.sortColumn.Ascending {
-				columnName = fmt.Sprintf("%s ↑", column.Name)
-			} else {
-				columnName = fmt.Sprintf("%s ↓", column.Name)
+		// append sort arrow to column name, with the priority if sorted
+		// by more than one column
+		if i := r.sortIndex(column.Name); i >= 0 {
+			arrow := "↓"
+			if r.sortColumns[i].Ascending {
+				arrow = "↑"
+			}
+
+			if len(r.sortColumns) > 1 {
+				arrow = fmt.Sprintf("%s%d", arrow, i+1)
 			}
+
+			columnName = fmt.Sprintf("%s %s", column.Name, arrow)
 		}
 
 		r.resultsTable.SetCell(
@@ -140,18 +195,23 @@ func (r *Results) RenderTable(table string, where string) error {
 	r.resultsTable.SetSelectedFunc(func(row, column int) {
 		// handle sort if headers
 		if row == 0 {
-			r.toggleSort(dbColumns[column].Name)
+			r.toggleSort(r.columns[column].Name)
 			return
 		}
 
 		// else show cell editor
-		r.pages.ShowPage("editor")
+		if r.db.ReadOnly() {
+			r.app.ShowError("Connection is read-only, cells can't be edited")
+			return
+		}
+
+		r.pages.ShowPage(r.cellEditor.page)
 		r.cellEditor.textArea.SetText(r.resultsTable.GetCell(row, column).Text, true)
 	})
 
 	// Iterate over records and fill table
 	for rowIndex, record := range dbRecords {
-		for columnIndex, column := range dbColumns {
+		for columnIndex, column := range r.columns {
 			recordValue, ok := record[column.Name]
 
 			cellString := ""
@@ -241,6 +301,16 @@ func (r *Results) renderFilterField() {
 		})
 }
 
+// SetBorderColor colors the borders of all the results pages
+func (r *Results) SetBorderColor(color tcell.Color) {
+	r.resultsPage.SetBorderColor(color)
+	r.structure.SetBorderColor(color)
+	r.query.SetBorderColor(color)
+	r.processes.SetBorderColor(color)
+	r.status.SetBorderColor(color)
+	r.users.SetBorderColor(color)
+}
+
 func (r *Results) Focus() {
 	// focus the active page content (table or columns)
 	frontPage, _ := r.view.GetFrontPage()
@@ -250,10 +320,69 @@ func (r *Results) Focus() {
 		r.app.SetFocus(r.resultsTable)
 	case "columns":
 		r.app.SetFocus(r.structure.view)
+	case "query":
+		r.app.SetFocus(r.query.view)
+	case "processes":
+		r.app.SetFocus(r.processes.table)
+	case "status":
+		r.app.SetFocus(r.status.variablesTable)
+	case "users":
+		r.app.SetFocus(r.users.usersTable)
 	}
 }
 
+// resultsPageKeys are the hotkeys of the Results pages
+var resultsPageKeys = map[rune]string{
+	'1': "columns",
+	'2': "results",
+	'3': "query",
+	'4': "processes",
+	'5': "status",
+	'6': "users",
+}
+
+// ShowPage switches to one of the Results pages, e.g. "processes"
+func (r *Results) ShowPage(name string) {
+	r.Stop()
+	r.view.SwitchToPage(name)
+	r.Start()
+	r.Focus()
+}
+
+// Start loads the visible page and starts its auto refresh, if it has one
+func (r *Results) Start() {
+	switch frontPage, _ := r.view.GetFrontPage(); frontPage {
+	case "processes":
+		r.processes.Start()
+	case "status":
+		r.status.Start()
+	case "users":
+		r.users.Refresh()
+	}
+}
+
+// Stop stops the auto refresh of all pages, e.g. when the tab is hidden
+func (r *Results) Stop() {
+	r.processes.Stop()
+	r.status.Stop()
+}
+
 func (r *Results) setKeyBindings() {
+	// Page hotkeys, unless typing in a filter or the SQL editor
+	r.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch r.app.GetFocus().(type) {
+		case *tview.InputField, *tview.TextArea:
+			return event
+		}
+
+		if page, ok := resultsPageKeys[event.Rune()]; ok && event.Key() == tcell.KeyRune {
+			r.ShowPage(page)
+			return nil
+		}
+
+		return event
+	})
+
 	// Resutls Table key bindings
 	r.resultsTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEscape {
@@ -266,19 +395,25 @@ func (r *Results) setKeyBindings() {
 				r.app.SetFocus(r.filter)
 			case event.Rune() == 's':
 				r.toggleSortForCell()
+			case event.Rune() == 'a':
+				r.addSortForCell()
+			case event.Rune() == 'x':
+				r.removeSortForCell()
 			case event.Rune() == 'r':
 				// refresh table
 				r.RefreshTable()
 			case event.Rune() == 'd':
 				r.attemptDeleteCell()
 			case event.Rune() == 'w':
-				r.filterCurrentColumn()
-			case event.Rune() == '1':
-				r.view.SwitchToPage("columns")
-				r.app.SetFocus(r.structure.view)
-			case event.Rune() == '3':
-				r.view.SwitchToPage("query")
-				r.app.SetFocus(r.query.view)
+				r.showQuickFilters()
+			case event.Rune() == 'm':
+				r.toggleMarkCell()
+			case event.Rune() == 'F':
+				r.showFilterBuilder()
+			case event.Rune() == 'E':
+				r.explain()
+			case event.Rune() == 'H':
+				r.showAllColumns()
 			case event.Rune() == 'y':
 				// Yank the cell text to clipboard
 				row, col := r.resultsTable.GetSelection()
@@ -311,6 +446,12 @@ func (r *Results) setKeyBindings() {
 			r.app.SetFocus(r.resultsTable)
 		}
 
+		// Ctrl+E to explain the query with the WHERE filter being typed
+		if event.Key() == tcell.KeyCtrlE {
+			r.explain()
+			return nil
+		}
+
 		return event
 	})
 }
@@ -329,37 +470,87 @@ func (r *Results) clearFilter() {
 	r.resultsTable.Select(currentRow, currentCol)
 }
 
+// toggleSort sorts by the column only, toggling from ASC, DESC, and none
 func (r *Results) toggleSort(columnName string) {
-	row, col := r.resultsTable.GetSelection()
+	_, col := r.resultsTable.GetSelection()
 
 	if columnName == "" {
-		columnName = r.dbColumns[col].Name
+		columnName = r.columns[col].Name
 	}
 
-	if r.sortColumn.Name == columnName {
-		// toggle from ASC, DESC, and none
-		switch r.sortColumn.Ascending {
-		case true:
-			r.sortColumn.Ascending = false
-		case false:
-			r.sortColumn.Name = ""
-		}
+	i := r.sortIndex(columnName)
+
+	switch {
+	case i < 0 || len(r.sortColumns) > 1:
+		r.setSort([]SortColumn{{Name: columnName, Ascending: true}})
+	case r.sortColumns[i].Ascending:
+		r.setSort([]SortColumn{{Name: columnName, Ascending: false}})
+	default:
+		r.setSort(nil)
+	}
+}
+
+func (r *Results) toggleSortForCell() {
+	r.toggleSort("")
+}
+
+// addSortForCell adds the selected column to the end of the sort, or
+// toggles its direction if the results are already sorted by it
+func (r *Results) addSortForCell() {
+	_, col := r.resultsTable.GetSelection()
+	if col >= len(r.columns) {
+		return
+	}
+
+	sortColumns := slices.Clone(r.sortColumns)
+
+	if i := r.sortIndex(r.columns[col].Name); i >= 0 {
+		sortColumns[i].Ascending = !sortColumns[i].Ascending
 	} else {
-		r.sortColumn.Name = columnName
-		r.sortColumn.Ascending = true
+		sortColumns = append(sortColumns, SortColumn{Name: r.columns[col].Name, Ascending: true})
 	}
 
-	// re-render table
-	r.RenderTable(r.selectedTable, r.filter.GetText())
+	r.setSort(sortColumns)
+}
 
-	// reselect current cell
-	r.resultsTable.Select(row, col)
+// removeSortForCell removes the selected column from the sort
+func (r *Results) removeSortForCell() {
+	_, col := r.resultsTable.GetSelection()
+	if col >= len(r.columns) {
+		return
+	}
+
+	i := r.sortIndex(r.columns[col].Name)
+	if i < 0 {
+		return
+	}
 
-	return
+	r.setSort(slices.Delete(slices.Clone(r.sortColumns), i, i+1))
 }
 
-func (r *Results) toggleSortForCell() {
-	r.toggleSort("")
+// sortIndex returns the priority of the column in the sort, -1 if the
+// results aren't sorted by it
+func (r *Results) sortIndex(columnName string) int {
+	return slices.IndexFunc(r.sortColumns, func(s SortColumn) bool { return s.Name == columnName })
+}
+
+// setSort sorts the results and remembers the sort of the table for when
+// it's selected again
+func (r *Results) setSort(sortColumns []SortColumn) {
+	r.sortColumns = sortColumns
+	r.rememberSort()
+
+	// re-render table and reselect current cell
+	r.RefreshTable()
+}
+
+// rememberSort saves the sort of the selected table
+func (r *Results) rememberSort() {
+	if len(r.sortColumns) == 0 {
+		delete(r.tableSorts, r.selectedTable)
+	} else {
+		r.tableSorts[r.selectedTable] = r.sortColumns
+	}
 }
 
 func (r *Results) attemptDeleteCell() {
@@ -374,10 +565,30 @@ func (r *Results) attemptDeleteCell() {
 }
 
 func (r *Results) hideColumn(col int) {
-	r.resultsTable.RemoveColumn(col)
+	if col >= len(r.columns) {
+		return
+	}
+
+	r.hiddenColumns = append(r.hiddenColumns, r.columns[col].Name)
+	r.RefreshTable()
+}
+
+// showAllColumns shows the columns hidden with hideColumn again
+func (r *Results) showAllColumns() {
+	if len(r.hiddenColumns) == 0 {
+		return
+	}
+
+	r.hiddenColumns = nil
+	r.RefreshTable()
 }
 
 func (r *Results) attemptDeleteRow(row int) {
+	if r.db.ReadOnly() {
+		r.app.ShowError("Connection is read-only, rows can't be deleted")
+		return
+	}
+
 	// if the selected row is already selected for delete, confirm deletion
 	if r.selectedRowForDelete == row {
 		r.deleteRow(r.selectedRowForDelete)
@@ -387,7 +598,7 @@ func (r *Results) attemptDeleteRow(row int) {
 
 	if r.selectedRowForDelete != 0 {
 		// clear the previous selected row for delete
-		for i := 0; i < len(r.dbColumns); i++ {
+		for i := 0; i < len(r.columns); i++ {
 			cell := r.resultsTable.GetCell(r.selectedRowForDelete, i)
 			cell.SetBackgroundColor(tcell.ColorDefault)
 		}
@@ -397,7 +608,7 @@ func (r *Results) attemptDeleteRow(row int) {
 	r.selectedRowForDelete = row
 
 	// set the selected row to red background
-	for i := 0; i < len(r.dbColumns); i++ {
+	for i := 0; i < len(r.columns); i++ {
 		cell := r.resultsTable.GetCell(row, i)
 		cell.SetBackgroundColor(tcell.ColorRed)
 	}
@@ -418,21 +629,21 @@ func (r *Results) deleteRow(row int) {
 	columns := r.dbColumns
 	where := ""
 
-	for i, col := range columns {
+	for _, col := range columns {
 		switch col.DataType {
 		case "longtext", "text", "blob", "json", "datetime":
 			continue
 		}
 
-		cell := r.resultsTable.GetCell(rowToDelete, i)
-
-		if cell.Text == "" {
+		// use the record since the column might be hidden
+		value := r.records[rowToDelete-1][col.Name]
+		if value == nil || fmt.Sprintf("%v", value) == "" {
 			continue
 		}
 
-		whereClause := fmt.Sprintf("%s = '%s'", col.Name, cell.Text)
+		whereClause := fmt.Sprintf("%s = '%v'", col.Name, value)
 
-		if i == 0 {
+		if where == "" {
 			where = whereClause
 		} else {
 			where = fmt.Sprintf("%s AND %s", where, whereClause)
@@ -440,7 +651,7 @@ func (r *Results) deleteRow(row int) {
 	}
 
 	if err := r.db.DeleteRecord(r.selectedTable, where); err != nil {
-		fmt.Printf("Error deleting record: %v\n", err)
+		r.app.ShowError(fmt.Sprintf("Error deleting record: %v", err))
 		return
 	}
 
@@ -479,9 +690,16 @@ func replaceLastWordWithSuggestion(originalText, suggestion string) string {
 	}
 }
 
+// filterCurrentColumn starts a condition on the selected column to type
+// the value of
 func (r *Results) filterCurrentColumn() {
 	_, col := r.resultsTable.GetSelection()
-	r.filter.SetText(fmt.Sprintf("%s = ", r.dbColumns[col].Name))
+	if col >= len(r.columns) {
+		return
+	}
+
+	condition := fmt.Sprintf("%s = ", db.QuoteIdentifier(r.columns[col].Name))
+	r.filter.SetText(db.AndWhere(r.filter.GetText(), condition))
 	r.app.SetFocus(r.filter)
 }
 
@@ -492,7 +710,101 @@ func (r *Results) RefreshTable() {
 	r.resultsTable.Select(row, col)
 }
 
-func (r *Results) ClearSort() {
-	r.sortColumn.Name = ""
-	r.sortColumn.Ascending = false
+func (r *Results) orderBy() string {
+	orderBy := make([]string, len(r.sortColumns))
+
+	for i, sortColumn := range r.sortColumns {
+		orderBy[i] = db.QuoteIdentifier(sortColumn.Name)
+		if !sortColumn.Ascending {
+			orderBy[i] += " DESC"
+		}
+	}
+
+	return strings.Join(orderBy, ", ")
+}
+
+// explain shows the plan of the query behind the results, e.g. to find out
+// why a WHERE filter is slow
+func (r *Results) explain() {
+	if r.selectedTable == "" {
+		return
+	}
+
+	query := db.RecordsQuery(r.selectedTable, r.filter.GetText(), r.orderBy())
+
+	if err := NewExplain(r.app, r.pages, r.db, query).Show(); err != nil {
+		r.app.ShowError(fmt.Sprintf("%v", err))
+	}
+}
+
+// Session returns the state of Results to restore on the next start
+func (r *Results) Session() config.TabSession {
+	page, _ := r.view.GetFrontPage()
+
+	var sorts map[string][]config.SortColumn
+	for table, sortColumns := range r.tableSorts {
+		if sorts == nil {
+			sorts = map[string][]config.SortColumn{}
+		}
+
+		for _, sortColumn := range sortColumns {
+			sorts[table] = append(sorts[table], config.SortColumn(sortColumn))
+		}
+	}
+
+	return config.TabSession{
+		Table:         r.selectedTable,
+		Where:         r.filter.GetText(),
+		HiddenColumns: r.hiddenColumns,
+		Page:          page,
+		Query:         r.query.textArea.GetText(),
+		Sorts:         sorts,
+	}
+}
+
+// Restore renders the table and page of a saved session
+func (r *Results) Restore(session config.TabSession) error {
+	r.query.textArea.SetText(session.Query, true)
+
+	r.tableSorts = map[string][]SortColumn{}
+	for table, sortColumns := range session.Sorts {
+		for _, sortColumn := range sortColumns {
+			r.tableSorts[table] = append(r.tableSorts[table], SortColumn(sortColumn))
+		}
+	}
+
+	if session.Table != "" {
+		r.sortColumns = r.tableSorts[session.Table]
+		r.hiddenColumns = session.HiddenColumns
+		r.filter.SetText(session.Where)
+
+		if err := r.RenderTable(session.Table, session.Where); err != nil {
+			return err
+		}
+	}
+
+	for _, page := range resultsPageKeys {
+		if page == session.Page {
+			r.ShowPage(page)
+		}
+	}
+
+	return nil
+}
+
+// LoadSort sorts the results like the last time the table was shown, call
+// it before rendering another table
+func (r *Results) LoadSort(table string) {
+	r.sortColumns = r.tableSorts[table]
+}
+
+// Clear empties Results and Structure, e.g. when the selected table was dropped
+func (r *Results) Clear() {
+	r.selectedTable = ""
+	r.dbColumns = nil
+	r.columns = nil
+	r.records = nil
+	r.resultsTable.Clear()
+	r.filter.SetText("")
+	r.structure.Clear()
 }
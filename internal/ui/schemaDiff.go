@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/atotto/clipboard"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// SchemaDiff lists the differences between the schema of the tab's
+// database and the database of another connection, e.g. to find the drift
+// between local, staging and production before a deploy
+type SchemaDiff struct {
+	app     *App
+	changes []db.SchemaChange
+	source  string
+	target  string
+	onClose func()
+	view    *tview.Flex
+	table   *tview.Table
+}
+
+// CompareSchema compares the tables of the tab's database with the
+// database of any connection. The schemas are loaded in the background,
+// every table takes a few queries on both servers.
+func (t *Tab) CompareSchema() {
+	if t.dbClient == nil || t.database == "" {
+		return
+	}
+
+	app := t.app
+	sourceClient := t.dbClient
+	source := fmt.Sprintf("%s: %s", t.connectionName, t.database)
+
+	pickConnection(t, "Compare schema with", func(connectionName string, client *db.DBClient) error {
+		progress := tview.NewTextView().
+			SetText(tview.Escape(fmt.Sprintf("Comparing %s with %s...", source, connectionName)))
+		progress.SetBorder(true).
+			SetTitle("Schema diff")
+
+		app.ShowModal("schema-diff-progress", progress, 70, 3)
+
+		go func() {
+			changes, err := db.DiffSchema(sourceClient, client)
+
+			database := ""
+			if err == nil {
+				database, err = client.GetCurrentDatabase()
+			}
+
+			app.QueueUpdateDraw(func() {
+				app.CloseModal("schema-diff-progress")
+
+				if err != nil {
+					app.pools.Release(client)
+					app.ShowError(fmt.Sprintf("%v", err))
+					return
+				}
+
+				target := fmt.Sprintf("%s: %s", connectionName, database)
+
+				NewSchemaDiff(app, changes, source, target, func() {
+					app.pools.Release(client)
+				}).Show()
+			})
+		}()
+
+		return nil
+	})
+}
+
+func NewSchemaDiff(
+	app *App,
+	changes []db.SchemaChange,
+	source string,
+	target string,
+	onClose func(),
+) *SchemaDiff {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	legend := tview.NewTextView().
+		SetText("[Enter] Statements  [g] SQL to make the target match  [Esc] Close").
+		SetTextColor(tcell.ColorYellow)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(legend, 1, 0, false)
+	view.SetBorder(true).
+		SetTitle(tview.Escape(fmt.Sprintf("Schema diff: %s -> %s (%d changes)", source, target, len(changes))))
+
+	schemaDiff := &SchemaDiff{
+		app:     app,
+		changes: changes,
+		source:  source,
+		target:  target,
+		onClose: onClose,
+		view:    view,
+		table:   table,
+	}
+
+	schemaDiff.setKeyBindings()
+	schemaDiff.render()
+
+	return schemaDiff
+}
+
+func (s *SchemaDiff) Show() {
+	s.app.ShowModal("schema-diff", s.view, 160, 40)
+	s.app.SetFocus(s.table)
+}
+
+func (s *SchemaDiff) setKeyBindings() {
+	s.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			s.close()
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			s.showChange()
+			return nil
+		case event.Rune() == 'g':
+			s.showSyncSQL()
+			return nil
+		}
+
+		return event
+	})
+}
+
+func (s *SchemaDiff) close() {
+	s.app.CloseModal("schema-diff")
+
+	if s.onClose != nil {
+		s.onClose()
+	}
+}
+
+// render lists the changes. Missing objects are green and extra objects
+// are red, like the added and removed rows of a data diff.
+func (s *SchemaDiff) render() {
+	s.table.Clear()
+
+	for i, header := range []string{"Table", "Change", "Details"} {
+		s.table.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, change := range s.changes {
+		color := tcell.ColorYellow
+
+		switch {
+		case strings.HasPrefix(change.Kind, "missing"):
+			color = tcell.ColorGreen
+		case strings.HasPrefix(change.Kind, "extra"):
+			color = tcell.ColorRed
+		}
+
+		s.table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(change.Table)))
+		s.table.SetCell(i+1, 1, tview.NewTableCell(change.Kind).SetTextColor(color))
+		s.table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(change.Description)).SetMaxWidth(80))
+	}
+
+	if len(s.changes) == 0 {
+		s.table.SetCell(1, 0, tview.NewTableCell("The schemas are the same").
+			SetSelectable(false))
+	}
+}
+
+// showChange shows the statements of the selected change
+func (s *SchemaDiff) showChange() {
+	row, _ := s.table.GetSelection()
+	if row < 1 || row > len(s.changes) {
+		return
+	}
+
+	change := s.changes[row-1]
+
+	s.showSQL(fmt.Sprintf("%s: %s", change.Table, change.Kind), change.Statements)
+}
+
+// showSyncSQL shows the statements that make the target schema match the
+// source schema
+func (s *SchemaDiff) showSyncSQL() {
+	if len(s.changes) == 0 {
+		s.app.ShowMessage("Schema diff", "The schemas are the same")
+		return
+	}
+
+	var statements []string
+	for _, change := range s.changes {
+		statements = append(statements, change.Statements...)
+	}
+
+	s.showSQL(fmt.Sprintf("SQL to make %s match (%d statements)", s.target, len(statements)), statements)
+}
+
+// showSQL shows the statements in a modal, y copies them
+func (s *SchemaDiff) showSQL(title string, statements []string) {
+	script := strings.Join(statements, ";\n\n") + ";"
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(highlightSQL(script))
+	textView.SetBorder(true).
+		SetTitle(tview.Escape(title + " - [y] Copy"))
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			s.app.CloseModal("schema-diff-sql")
+			return nil
+		case event.Rune() == 'y':
+			clipboard.WriteAll(script)
+			s.app.CloseModal("schema-diff-sql")
+			return nil
+		}
+
+		return event
+	})
+
+	s.app.ShowModal("schema-diff-sql", textView, 120, modalHeight(script, 0))
+}
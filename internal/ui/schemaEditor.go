@@ -0,0 +1,269 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/rivo/tview"
+)
+
+// Schema editing actions of the Structure page. Every action generates an
+// ALTER TABLE statement which is shown for confirmation before it runs.
+
+func (s *Structure) selectedColumn() (db.Column, bool) {
+	row, _ := s.columnsTable.GetSelection()
+	if row == 0 {
+		return db.Column{}, false
+	}
+
+	column, ok := s.columnsTable.GetCell(row, 0).GetReference().(db.Column)
+
+	return column, ok
+}
+
+// canEdit reports whether the schema can be changed, showing an error if not
+func (s *Structure) canEdit() bool {
+	if s.tableName == "" {
+		return false
+	}
+
+	if s.db.ReadOnly() {
+		s.app.ShowError("Connection is read-only, the schema can't be changed")
+		return false
+	}
+
+	return true
+}
+
+// showColumnForm shows a form to add a column, or to modify the given column
+func (s *Structure) showColumnForm(column *db.Column) {
+	if !s.canEdit() {
+		return
+	}
+
+	definition := db.ColumnDefinition{Type: "varchar(255)", Nullable: true}
+	title := fmt.Sprintf("Add column to %s", s.tableName)
+	after := ""
+
+	if column != nil {
+		definition = column.Definition()
+		title = fmt.Sprintf("Modify column %s.%s", s.tableName, column.Name)
+	} else if selected, ok := s.selectedColumn(); ok {
+		after = selected.Name
+	}
+
+	form := newModalForm(s.app, "schema-form", title)
+	addColumnFields(form, definition, column == nil)
+
+	if column == nil {
+		form.AddInputField("After", after, 40, nil, nil)
+	}
+
+	form.AddButton("Preview", func() {
+		definition = columnFromForm(form, definition)
+
+		var statement string
+		if column == nil {
+			if definition.Name == "" {
+				s.app.ShowError("Column name is required")
+				return
+			}
+			statement = db.AddColumnSQL(s.tableName, definition, formText(form, "After"))
+		} else {
+			statement = db.ModifyColumnSQL(s.tableName, definition)
+		}
+
+		s.confirmStatement(title, statement)
+	})
+	form.AddButton("Cancel", s.closeSchemaForm)
+
+	s.app.ShowModal("schema-form", form, 70, form.GetFormItemCount()*2+5)
+}
+
+func (s *Structure) showRenameColumnForm(column db.Column) {
+	if !s.canEdit() {
+		return
+	}
+
+	title := fmt.Sprintf("Rename column %s.%s", s.tableName, column.Name)
+
+	form := newModalForm(s.app, "schema-form", title)
+	form.AddInputField("New name", column.Name, 40, nil, nil)
+
+	form.AddButton("Preview", func() {
+		newName := formText(form, "New name")
+		if newName == "" || newName == column.Name {
+			return
+		}
+
+		s.confirmStatement(title, db.RenameColumnSQL(s.tableName, column.Name, newName))
+	})
+	form.AddButton("Cancel", s.closeSchemaForm)
+
+	s.app.ShowModal("schema-form", form, 70, 7)
+}
+
+func (s *Structure) dropColumn(column db.Column) {
+	if !s.canEdit() {
+		return
+	}
+
+	s.confirmStatement(
+		fmt.Sprintf("Drop column %s.%s", s.tableName, column.Name),
+		db.DropColumnSQL(s.tableName, column.Name),
+	)
+}
+
+// showIndexForm shows a form to add an index on the selected column
+func (s *Structure) showIndexForm() {
+	if !s.canEdit() {
+		return
+	}
+
+	columnName := ""
+	if column, ok := s.selectedColumn(); ok {
+		columnName = column.Name
+	}
+
+	title := fmt.Sprintf("Add index to %s", s.tableName)
+
+	form := newModalForm(s.app, "schema-form", title)
+	form.AddInputField("Name", "idx_"+columnName, 40, nil, nil).
+		AddInputField("Columns", columnName, 40, nil, nil).
+		AddCheckbox("Unique", false, nil)
+
+	form.GetFormItemByLabel("Columns").(*tview.InputField).
+		SetPlaceholder("comma separated, e.g. user_id, created_at")
+
+	form.AddButton("Preview", func() {
+		index := db.IndexDefinition{
+			Name:    formText(form, "Name"),
+			Columns: splitList(formText(form, "Columns")),
+			Unique:  formChecked(form, "Unique"),
+		}
+
+		if index.Name == "" || len(index.Columns) == 0 {
+			s.app.ShowError("Index name and columns are required")
+			return
+		}
+
+		s.confirmStatement(title, db.AddIndexSQL(s.tableName, index))
+	})
+	form.AddButton("Cancel", s.closeSchemaForm)
+
+	s.app.ShowModal("schema-form", form, 70, 11)
+}
+
+// dropSelectedIndex drops the index of the selected row in the indexes table
+func (s *Structure) dropSelectedIndex() {
+	if !s.canEdit() {
+		return
+	}
+
+	row, _ := s.indexesTable.GetSelection()
+	if row == 0 {
+		return
+	}
+
+	for col := 0; col < s.indexesTable.GetColumnCount(); col++ {
+		if s.indexesTable.GetCell(0, col).Text != "Key_name" {
+			continue
+		}
+
+		indexName := s.indexesTable.GetCell(row, col).Text
+
+		s.confirmStatement(
+			fmt.Sprintf("Drop index %s.%s", s.tableName, indexName),
+			db.DropIndexSQL(s.tableName, indexName),
+		)
+
+		return
+	}
+}
+
+// confirmStatement shows the statement and runs it once confirmed
+func (s *Structure) confirmStatement(title string, statement string) {
+	s.app.Confirm(title, highlightSQL(statement), func() {
+		if err := s.db.Exec(statement); err != nil {
+			s.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		s.closeSchemaForm()
+		s.refresh()
+	})
+}
+
+func (s *Structure) closeSchemaForm() {
+	s.app.CloseModal("schema-form")
+}
+
+// refresh re-renders Results and Structure after the schema changed
+func (s *Structure) refresh() {
+	row, col := s.columnsTable.GetSelection()
+
+	if err := s.results.RenderTable(s.tableName, s.results.filter.GetText()); err != nil {
+		// e.g. the WHERE filter uses a dropped column, still show the new structure
+		dbColumns, err := s.db.GetColumns(s.tableName)
+		if err != nil {
+			s.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		s.Render(s.tableName, dbColumns)
+	}
+
+	s.columnsTable.Select(row, col)
+}
+
+// addColumnFields adds the fields of a column definition to the form, the
+// name is optional since it can't be changed when modifying a column
+func addColumnFields(form *tview.Form, definition db.ColumnDefinition, withName bool) {
+	if withName {
+		form.AddInputField("Name", definition.Name, 40, nil, nil)
+	}
+
+	form.AddInputField("Type", definition.Type, 40, nil, nil).
+		AddCheckbox("Nullable", definition.Nullable, nil).
+		AddInputField("Default", definition.Default, 40, nil, nil).
+		AddCheckbox("Auto increment", definition.AutoIncrement, nil)
+
+	form.GetFormItemByLabel("Default").(*tview.InputField).
+		SetPlaceholder("none, NULL, CURRENT_TIMESTAMP, 'text', ...")
+}
+
+// columnFromForm reads the fields added by addColumnFields
+func columnFromForm(form *tview.Form, definition db.ColumnDefinition) db.ColumnDefinition {
+	if form.GetFormItemIndex("Name") >= 0 {
+		definition.Name = formText(form, "Name")
+	}
+
+	definition.Type = formText(form, "Type")
+	definition.Nullable = formChecked(form, "Nullable")
+	definition.Default = formText(form, "Default")
+	definition.AutoIncrement = formChecked(form, "Auto increment")
+
+	return definition
+}
+
+func formText(form *tview.Form, label string) string {
+	return strings.TrimSpace(form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+}
+
+func formChecked(form *tview.Form, label string) bool {
+	return form.GetFormItemByLabel(label).(*tview.Checkbox).IsChecked()
+}
+
+// splitList splits a comma separated list, ignoring empty entries
+func splitList(text string) []string {
+	var items []string
+
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
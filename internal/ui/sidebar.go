@@ -9,14 +9,27 @@ import (
 	"github.com/rivo/tview"
 )
 
+// sidebarGroup is a collapsible group of database objects in the sidebar tree
+type sidebarGroup struct {
+	title    string
+	types    []db.ObjectType
+	color    tcell.Color
+	expanded bool
+}
+
 type Sidebar struct {
-	tab     *Tab
-	app     *tview.Application
-	view    *tview.Flex
-	list    *tview.List
-	db      *db.DBClient
-	results *Results
-	filter  *tview.InputField
+	tab        *Tab
+	app        *tview.Application
+	view       *tview.Flex
+	tree       *tview.TreeView
+	db         *db.DBClient
+	results    *Results
+	filter     *tview.InputField
+	objects    []db.DBObject
+	groups     []*sidebarGroup
+	tableInfo  map[string]db.TableInfo
+	showInfo   bool
+	sortBySize bool
 }
 
 func NewSidebar(
@@ -25,7 +38,7 @@ func NewSidebar(
 	db *db.DBClient,
 	results *Results,
 ) (*Sidebar, error) {
-	list := tview.NewList()
+	tree := tview.NewTreeView()
 	filter := tview.NewInputField()
 
 	// Sidebar main container
@@ -34,20 +47,26 @@ func NewSidebar(
 	view.SetBorder(true)
 	view.SetDirection(tview.FlexRow).
 		AddItem(filter, 1, 1, false).
-		AddItem(list, 0, 1, true)
+		AddItem(tree, 0, 1, true)
 
 	sidebar := &Sidebar{
 		view:    view,
-		list:    list,
+		tree:    tree,
 		db:      db,
 		results: results,
 		app:     app,
 		filter:  filter,
 		tab:     tab,
+		groups:  newSidebarGroups(),
 	}
 
+	tree.SetRoot(tview.NewTreeNode("")).
+		SetTopLevel(1).
+		SetGraphics(false).
+		SetPrefixes([]string{"", "  "})
+
 	// Render all components
-	if err := sidebar.renderTableList(""); err != nil {
+	if err := sidebar.Refresh(); err != nil {
 		return nil, fmt.Errorf("Failed to render table list: %w", err)
 	}
 	sidebar.renderFilterField()
@@ -56,86 +75,303 @@ func NewSidebar(
 	return sidebar, nil
 }
 
+func newSidebarGroups() []*sidebarGroup {
+	return []*sidebarGroup{
+		{title: "Tables", types: []db.ObjectType{db.ObjectTable}, color: tcell.ColorWhite, expanded: true},
+		{title: "Views", types: []db.ObjectType{db.ObjectView}, color: tcell.ColorLightCyan, expanded: true},
+		{
+			title: "Routines",
+			types: []db.ObjectType{db.ObjectProcedure, db.ObjectFunction},
+			color: tcell.ColorYellow,
+		},
+		{title: "Triggers", types: []db.ObjectType{db.ObjectTrigger}, color: tcell.ColorFuchsia},
+		{title: "Events", types: []db.ObjectType{db.ObjectEvent}, color: tcell.ColorLightGreen},
+	}
+}
+
 func (sidebar *Sidebar) setKeyBindings() {
 	sidebar.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if sidebar.app.GetFocus() != sidebar.filter {
-			// Ctrl+n / Ctrl+p to navigate the list
+			// Ctrl+n / Ctrl+p to navigate the tree
 			if event.Key() == tcell.KeyCtrlN {
-				sidebar.list.SetCurrentItem(sidebar.list.GetCurrentItem() + 1)
-				tableName, _ := sidebar.list.GetItemText(sidebar.list.GetCurrentItem())
-				sidebar.selectTable(tableName, false)
-				return event
+				sidebar.tree.Move(1)
+				sidebar.previewCurrentNode()
+				return nil
 			}
 			if event.Key() == tcell.KeyCtrlP {
-				sidebar.list.SetCurrentItem(sidebar.list.GetCurrentItem() - 1)
-				tableName, _ := sidebar.list.GetItemText(sidebar.list.GetCurrentItem())
-				sidebar.selectTable(tableName, false)
-				return event
+				sidebar.tree.Move(-1)
+				sidebar.previewCurrentNode()
+				return nil
 			}
 
 			if event.Key() == tcell.KeyRune {
 				switch event.Rune() {
 				case 'j':
-					// pressing j at the end of the list goes to the top
-					if sidebar.list.GetItemCount()-1 == sidebar.list.GetCurrentItem() {
-						sidebar.list.SetCurrentItem(0)
-					} else {
-						sidebar.list.SetCurrentItem(sidebar.list.GetCurrentItem() + 1)
+					// pressing j at the end of the tree goes to the top
+					current := sidebar.tree.GetCurrentNode()
+					sidebar.tree.Move(1)
+					if sidebar.tree.GetCurrentNode() == current {
+						sidebar.tree.SetCurrentNode(sidebar.firstNode())
 					}
-				case 'k':
-					sidebar.list.SetCurrentItem(sidebar.list.GetCurrentItem() - 1)
+					return nil
 				case '/':
 					sidebar.app.SetFocus(sidebar.filter)
 					return nil // prevents adding '/' char to the input field
+				case 'n':
+					sidebar.showTableWizard()
+					return nil
+				case 'x':
+					sidebar.showTableActions()
+					return nil
+				case 'i':
+					sidebar.toggleInfo()
+					return nil
+				case 'I':
+					sidebar.showTableInfo()
+					return nil
+				case 'C':
+					sidebar.compareTableData()
+					return nil
+				case 'e':
+					sidebar.showDump()
+					return nil
+				case 'r':
+					sidebar.showRestore()
+					return nil
+				case 's':
+					sidebar.toggleSortBySize()
+					return nil
 				}
 			}
 
 			// Clear filter when pressing escape
 			if event.Key() == tcell.KeyEscape && sidebar.filter.GetText() != "" {
 				sidebar.filter.SetText("")
-				sidebar.renderTableList("")
+				sidebar.renderTree("")
 			}
 		}
 		return event
 	})
 
-	sidebar.list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyTab {
-			// Hack: For some reason, tab key moves the focus to the next item
-			// so we need to move it back to the previous item
-			sidebar.list.SetCurrentItem(sidebar.list.GetCurrentItem() - 1)
+	sidebar.tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		switch reference := node.GetReference().(type) {
+		case *sidebarGroup:
+			reference.expanded = !reference.expanded
+			node.SetExpanded(reference.expanded)
+		case db.DBObject:
+			sidebar.openObject(reference)
 		}
-		return event
 	})
 }
 
-func (s *Sidebar) renderTableList(filter string) error {
-	s.list.Clear()
-
-	tableNames, err := s.db.GetTables()
+// Refresh reloads the database objects and re-renders the tree
+func (s *Sidebar) Refresh() error {
+	objects, err := s.db.GetObjects()
 	if err != nil {
 		return fmt.Errorf("Failed to get tables: %w", err)
 	}
 
-	s.list.ShowSecondaryText(false).SetHighlightFullLine(true).
-		SetTitle("Tables")
+	s.objects = objects
+
+	if err := s.loadTableInfo(); err != nil {
+		return err
+	}
+
+	return s.renderTree(s.filter.GetText())
+}
+
+func (s *Sidebar) SetBorderColor(color tcell.Color) {
+	s.view.SetBorderColor(color)
+}
+
+// renderTree renders the objects matching the filter, grouped by type
+func (s *Sidebar) renderTree(filter string) error {
+	root := s.tree.GetRoot()
+	root.ClearChildren()
+
+	var currentNode *tview.TreeNode
+	var firstMatch *tview.TreeNode
+
+	currentObject, hasCurrentObject := s.currentObject()
+
+	for _, group := range s.groups {
+		groupNode := tview.NewTreeNode("").
+			SetReference(group).
+			SetColor(group.color)
+
+		count := 0
+
+		for _, object := range s.sortedObjects() {
+			if !group.contains(object.Type) {
+				continue
+			}
+
+			if filter != "" && !strings.Contains(strings.ToLower(object.Name), strings.ToLower(filter)) {
+				continue
+			}
+
+			text := objectText(object)
+			if s.showInfo {
+				text += s.infoText(object)
+			}
 
-	for _, table := range tableNames {
-		if filter != "" && !strings.Contains(strings.ToLower(table), strings.ToLower(filter)) {
+			objectNode := tview.NewTreeNode(text).
+				SetReference(object).
+				SetColor(group.color)
+
+			groupNode.AddChild(objectNode)
+			count++
+
+			if firstMatch == nil {
+				firstMatch = objectNode
+			}
+
+			if hasCurrentObject && object == currentObject {
+				currentNode = objectNode
+			}
+		}
+
+		// Don't show empty groups, e.g. no triggers
+		if count == 0 {
 			continue
 		}
 
-		s.list.AddItem(table, "", 0, func() {
-			s.selectTable(table, true)
-		})
+		groupNode.SetText(fmt.Sprintf("%s (%d)", group.title, count))
+		if s.sortBySize && group.contains(db.ObjectTable) {
+			groupNode.SetText(fmt.Sprintf("%s (%d) by size", group.title, count))
+		}
+
+		// Always show the matches while filtering
+		groupNode.SetExpanded(group.expanded || filter != "")
+
+		root.AddChild(groupNode)
+	}
+
+	switch {
+	case filter != "" && firstMatch != nil:
+		s.tree.SetCurrentNode(firstMatch)
+	case currentNode != nil:
+		s.tree.SetCurrentNode(currentNode)
+	case firstMatch != nil:
+		s.tree.SetCurrentNode(firstMatch)
 	}
 
 	return nil
 }
 
+// firstNode returns the node at the top of the tree, the first group
+func (s *Sidebar) firstNode() *tview.TreeNode {
+	children := s.tree.GetRoot().GetChildren()
+	if len(children) == 0 {
+		return nil
+	}
+
+	return children[0]
+}
+
+func (g *sidebarGroup) contains(objectType db.ObjectType) bool {
+	for _, t := range g.types {
+		if t == objectType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// objectText renders the name of the object, with its type when a group
+// holds more than one type (procedures and functions)
+func objectText(object db.DBObject) string {
+	switch object.Type {
+	case db.ObjectProcedure:
+		return fmt.Sprintf("%s [gray]proc", tview.Escape(object.Name))
+	case db.ObjectFunction:
+		return fmt.Sprintf("%s [gray]func", tview.Escape(object.Name))
+	}
+
+	return tview.Escape(object.Name)
+}
+
+// currentObject returns the object of the selected node, if any
+func (s *Sidebar) currentObject() (db.DBObject, bool) {
+	node := s.tree.GetCurrentNode()
+	if node == nil {
+		return db.DBObject{}, false
+	}
+
+	object, ok := node.GetReference().(db.DBObject)
+
+	return object, ok
+}
+
+// selectTableNode selects the node of the table or view without showing it
+// in Results, e.g. when the table was rendered from a saved session
+func (s *Sidebar) selectTableNode(table string) {
+	for _, groupNode := range s.tree.GetRoot().GetChildren() {
+		for _, node := range groupNode.GetChildren() {
+			object, ok := node.GetReference().(db.DBObject)
+			if !ok || object.Name != table {
+				continue
+			}
+
+			if object.Type == db.ObjectTable || object.Type == db.ObjectView {
+				groupNode.GetReference().(*sidebarGroup).expanded = true
+				groupNode.SetExpanded(true)
+				s.tree.SetCurrentNode(node)
+				return
+			}
+		}
+	}
+}
+
+// focusObject selects the node of the object, e.g. after it was created,
+// and shows it in Results
+func (s *Sidebar) focusObject(object db.DBObject) {
+	for _, groupNode := range s.tree.GetRoot().GetChildren() {
+		for _, node := range groupNode.GetChildren() {
+			if node.GetReference() == object {
+				groupNode.GetReference().(*sidebarGroup).expanded = true
+				groupNode.SetExpanded(true)
+				s.tree.SetCurrentNode(node)
+				s.app.SetFocus(s.tree)
+				s.previewCurrentNode()
+				return
+			}
+		}
+	}
+}
+
+// openObject shows tables and views in Results, and the definition of
+// routines, triggers and events in a read-only viewer
+func (s *Sidebar) openObject(object db.DBObject) {
+	switch object.Type {
+	case db.ObjectTable, db.ObjectView:
+		s.selectTable(object.Name, true)
+	default:
+		s.showDefinition(object)
+	}
+}
+
+// previewCurrentNode renders the selected table without moving the focus
+func (s *Sidebar) previewCurrentNode() {
+	object, ok := s.currentObject()
+	if !ok {
+		return
+	}
+
+	if object.Type == db.ObjectTable || object.Type == db.ObjectView {
+		s.selectTable(object.Name, false)
+	}
+}
+
 func (s *Sidebar) selectTable(table string, focus bool) {
-	s.results.ClearSort()
-	s.results.RenderTable(table, "")
+	s.results.LoadSort(table)
+	s.results.hiddenColumns = nil
+
+	if err := s.results.RenderTable(table, ""); err != nil {
+		s.tab.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
 
 	if focus {
 		s.results.Focus()
@@ -144,39 +380,76 @@ func (s *Sidebar) selectTable(table string, focus bool) {
 	s.tab.UpdateTabName(table)
 }
 
+func (s *Sidebar) showDefinition(object db.DBObject) {
+	definition, err := s.db.GetDefinition(object)
+	if err != nil {
+		s.tab.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	textView := tview.NewTextView().
+		SetText(highlightSQL(definition)).
+		SetDynamicColors(true)
+	textView.SetBorder(true).
+		SetTitle(fmt.Sprintf("%s %s (read-only)", object.Type, object.Name))
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			s.tab.pages.RemovePage("definition")
+			s.app.SetFocus(s.tree)
+			return nil
+		}
+
+		return event
+	})
+
+	s.tab.pages.RemovePage("definition")
+	s.tab.pages.AddPage("definition", textView, true, true)
+	s.app.SetFocus(textView)
+}
+
+func (s *Sidebar) showTableWizard() {
+	if s.db.ReadOnly() {
+		s.tab.app.ShowError("Connection is read-only, tables can't be created")
+		return
+	}
+
+	wizard := NewTableWizard(s)
+
+	s.tab.pages.RemovePage("create-table")
+	s.tab.pages.AddPage("create-table", wizard.view, true, true)
+	s.app.SetFocus(wizard.form)
+}
+
 func (s *Sidebar) renderFilterField() {
 	s.filter.SetLabel("Filter ")
 	s.filter.SetFieldBackgroundColor(tcell.ColorNone)
 
 	s.filter.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		currentItem := s.list.GetCurrentItem()
-
-		// Set the focus back to the list on tab key
+		// Set the focus back to the tree on tab key
 		if event.Key() == tcell.KeyTab {
-			s.app.SetFocus(s.list)
+			s.app.SetFocus(s.tree)
 			return event
 		}
 
-		// Ctrl+n / Ctrl+p to navigate the list
+		// Ctrl+n / Ctrl+p to navigate the tree
 		if event.Key() == tcell.KeyCtrlN {
-			s.list.SetCurrentItem(currentItem + 1)
-			tableName, _ := s.list.GetItemText(s.list.GetCurrentItem())
-			s.selectTable(tableName, false)
-			return event
+			s.tree.Move(1)
+			s.previewCurrentNode()
+			return nil
 		}
 		if event.Key() == tcell.KeyCtrlP {
-			s.list.SetCurrentItem(currentItem - 1)
-			tableName, _ := s.list.GetItemText(s.list.GetCurrentItem())
-			s.selectTable(tableName, false)
-			return event
+			s.tree.Move(-1)
+			s.previewCurrentNode()
+			return nil
 		}
 
-		// Filter the table list in real time
+		// Filter the tree in real time
 		currentText := s.filter.GetText()
 		if event.Key() == tcell.KeyEscape {
 			if currentText != "" {
-				s.renderTableList(currentText)
-				s.app.SetFocus(s.list)
+				s.renderTree(currentText)
+				s.app.SetFocus(s.tree)
 			}
 			return event
 		}
@@ -193,26 +466,21 @@ func (s *Sidebar) renderFilterField() {
 			currentText += string(event.Rune())
 		}
 
-		// Render the table list and filter in real time
-		s.renderTableList(currentText)
-
-		s.list.SetCurrentItem(currentItem)
+		// Render the tree and filter in real time
+		s.renderTree(currentText)
 
 		return event
 	})
 
 	s.filter.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEnter {
-			currentItem := s.list.GetCurrentItem()
-
-			// if current item is > 0, select the item
-			if currentItem > 0 {
-				tableName, _ := s.list.GetItemText(currentItem)
-				s.selectTable(tableName, true)
+			// open the selected object, e.g. the first match of the filter
+			if object, ok := s.currentObject(); ok {
+				s.openObject(object)
 				return
 			}
 
-			s.app.SetFocus(s.list)
+			s.app.SetFocus(s.tree)
 		}
 	})
 }
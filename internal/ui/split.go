@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/rivo/tview"
+)
+
+// Split is a second Results panel in a tab with its own connection and
+// table, e.g. to compare a row in staging against production
+type Split struct {
+	tab            *Tab
+	db             *db.DBClient
+	connectionName string
+	results        *Results
+	stacked        bool
+	syncScroll     bool
+	syncing        bool
+}
+
+// ShowSplit asks for the connection and table of a second panel, or closes
+// the panel if it's already open
+func (t *Tab) ShowSplit() {
+	if t.results == nil {
+		return
+	}
+
+	if t.split != nil {
+		t.CloseSplit()
+		return
+	}
+
+	pickTable(t, "Compare with", t.openSplit)
+}
+
+// openSplit shows the table of the connection next to the tab's Results
+func (t *Tab) openSplit(connectionName string, client *db.DBClient, table string) error {
+	results, err := NewResults(t.app, t.pages, client)
+	if err != nil {
+		return err
+	}
+
+	cellEditor, err := NewCellEditor(t.app, t.pages, "split-editor", results, client)
+	if err != nil {
+		return err
+	}
+
+	results.cellEditor = cellEditor
+
+	if err := results.RenderTable(table, ""); err != nil {
+		return err
+	}
+
+	split := &Split{
+		tab:            t,
+		db:             client,
+		connectionName: connectionName,
+		results:        results,
+	}
+
+	t.pages.AddPage("split-editor", cellEditor.view, true, false)
+	t.panels.AddItem(results.view, 0, 1, false)
+	t.split = split
+
+	split.renderTitle()
+	split.setKeyBindings()
+	results.Focus()
+
+	return nil
+}
+
+// CloseSplit closes the second panel and releases its connection
+func (t *Tab) CloseSplit() {
+	if t.split == nil {
+		return
+	}
+
+	t.split.results.Stop()
+	t.app.pools.Release(t.split.db)
+
+	t.panels.RemoveItem(t.split.results.view)
+	t.pages.RemovePage("split-editor")
+
+	t.results.resultsTable.SetSelectionChangedFunc(nil)
+	t.split = nil
+
+	t.results.Focus()
+}
+
+// ToggleSplitLayout shows the panels side by side or stacked
+func (t *Tab) ToggleSplitLayout() {
+	if t.split == nil {
+		return
+	}
+
+	t.split.stacked = !t.split.stacked
+
+	if t.split.stacked {
+		t.panels.SetDirection(tview.FlexRow)
+	} else {
+		t.panels.SetDirection(tview.FlexColumn)
+	}
+}
+
+// ToggleSplitSync turns the synchronized scrolling of the panels on or off
+func (t *Tab) ToggleSplitSync() {
+	if t.split == nil {
+		return
+	}
+
+	t.split.syncScroll = !t.split.syncScroll
+	t.split.renderTitle()
+}
+
+func (s *Split) setKeyBindings() {
+	// moving in one panel selects the same row and column in the other
+	s.tab.results.resultsTable.SetSelectionChangedFunc(func(row, column int) {
+		s.syncSelection(s.tab.results, s.results, row, column)
+	})
+
+	s.results.resultsTable.SetSelectionChangedFunc(func(row, column int) {
+		s.syncSelection(s.results, s.tab.results, row, column)
+	})
+}
+
+// syncSelection selects the row of the source panel in the target panel,
+// and the column with the same name, since the panels can have different
+// or hidden columns
+func (s *Split) syncSelection(source *Results, target *Results, row int, column int) {
+	if !s.syncScroll || s.syncing || row >= target.resultsTable.GetRowCount() {
+		return
+	}
+
+	targetColumn := column
+	if column < len(source.columns) {
+		name := source.columns[column].Name
+
+		if i := slices.IndexFunc(target.columns, func(c db.Column) bool { return c.Name == name }); i >= 0 {
+			targetColumn = i
+		}
+	}
+
+	s.syncing = true
+	target.resultsTable.Select(row, targetColumn)
+	s.syncing = false
+}
+
+func (s *Split) renderTitle() {
+	title := fmt.Sprintf("Results - %s: %s", s.connectionName, s.results.selectedTable)
+	if s.syncScroll {
+		title += " [synced]"
+	}
+
+	s.results.resultsPage.SetTitle(tview.Escape(title))
+}
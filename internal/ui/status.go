@@ -0,0 +1,307 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const statusRefreshInterval = 2 * time.Second
+
+// Status is a dashboard of the server status counters, with the change
+// since the previous refresh, and a filterable list of the server variables
+type Status struct {
+	app            *App
+	db             *db.DBClient
+	view           *tview.Flex
+	dashboard      *tview.TextView
+	filter         *tview.InputField
+	variablesView  *tview.Flex
+	variablesTable *tview.Table
+	variables      map[string]string
+	status         map[string]string
+	previous       map[string]string
+	refreshedAt    time.Time
+	interval       time.Duration
+	refresher      *autoRefresh
+}
+
+func NewStatus(app *App, db *db.DBClient) (*Status, error) {
+	dashboard := tview.NewTextView().
+		SetDynamicColors(true)
+	dashboard.SetBorder(true).
+		SetTitle("Server status")
+
+	filter := tview.NewInputField().
+		SetLabel("Filter ").
+		SetFieldBackgroundColor(tcell.ColorNone)
+
+	variablesTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	variablesView := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, false).
+		AddItem(variablesTable, 0, 1, true)
+	variablesView.SetBorder(true).
+		SetTitle("Variables")
+
+	legend := tview.NewTextView().
+		SetText("[r] Refresh  [/] Filter variables").
+		SetTextColor(tcell.ColorYellow)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(dashboard, 11, 0, false).
+		AddItem(variablesView, 0, 1, true).
+		AddItem(legend, 1, 0, false)
+
+	status := &Status{
+		app:            app,
+		db:             db,
+		view:           view,
+		dashboard:      dashboard,
+		filter:         filter,
+		variablesView:  variablesView,
+		variablesTable: variablesTable,
+	}
+
+	status.refresher = newAutoRefresh(app, statusRefreshInterval, status.loadStatus)
+	status.setKeyBindings()
+
+	return status, nil
+}
+
+func (s *Status) setKeyBindings() {
+	s.variablesTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape && s.filter.GetText() != "" {
+			s.filter.SetText("")
+			return nil
+		}
+
+		switch event.Rune() {
+		case '/':
+			s.app.SetFocus(s.filter)
+		case 'r':
+			s.Refresh()
+		default:
+			return event
+		}
+
+		return nil
+	})
+
+	s.filter.SetChangedFunc(func(text string) {
+		s.renderVariables()
+	})
+
+	s.filter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			s.filter.SetText("")
+		}
+
+		s.app.SetFocus(s.variablesTable)
+	})
+}
+
+func (s *Status) SetBorderColor(color tcell.Color) {
+	s.dashboard.SetBorderColor(color)
+	s.variablesView.SetBorderColor(color)
+}
+
+// Start loads the status and refreshes it until Stop is called
+func (s *Status) Start() {
+	s.Refresh()
+	s.refresher.Start()
+}
+
+func (s *Status) Stop() {
+	s.refresher.Stop()
+}
+
+// Refresh reloads the variables and the status in the background
+func (s *Status) Refresh() {
+	loadInBackground(s.app, func() func() {
+		variables, err := s.db.GetGlobalVariables()
+		if err != nil {
+			return func() {
+				s.app.ShowError(fmt.Sprintf("%v", err))
+			}
+		}
+
+		updateStatus := s.loadStatus()
+
+		return func() {
+			s.variables = variables
+			s.renderVariables()
+
+			// start over, the deltas would span the time the page was hidden
+			s.status = nil
+			s.previous = nil
+			updateStatus()
+		}
+	})
+}
+
+// loadStatus gets the status counters, the returned function renders them
+func (s *Status) loadStatus() func() {
+	status, err := s.db.GetGlobalStatus()
+	now := time.Now()
+
+	return func() {
+		if err != nil {
+			s.Stop()
+			s.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		if s.status != nil {
+			s.previous = s.status
+			s.interval = now.Sub(s.refreshedAt)
+		}
+
+		s.status = status
+		s.refreshedAt = now
+
+		s.renderDashboard()
+	}
+}
+
+func (s *Status) renderDashboard() {
+	uptime := s.counter("Uptime")
+
+	// Average since the server started until there is a previous refresh
+	qps := 0.0
+	if s.previous != nil && s.interval > 0 {
+		qps = float64(s.delta("Queries")) / s.interval.Seconds()
+	} else if uptime > 0 {
+		qps = float64(s.counter("Queries")) / float64(uptime)
+	}
+
+	hitRate := "-"
+	if requests := s.counter("Innodb_buffer_pool_read_requests"); requests > 0 {
+		rate := 100 * (1 - float64(s.counter("Innodb_buffer_pool_reads"))/float64(requests))
+		hitRate = fmt.Sprintf("%.2f%%", rate)
+	}
+
+	lines := [][2]string{
+		{"Version", strings.TrimSpace(s.variables["version"] + " " + s.variables["version_comment"])},
+		{"Uptime", formatUptime(uptime)},
+		{"Connections", fmt.Sprintf(
+			"%d / %s max, %d total%s",
+			s.counter("Threads_connected"),
+			s.variables["max_connections"],
+			s.counter("Connections"),
+			s.deltaText("Connections"),
+		)},
+		{"Threads", fmt.Sprintf(
+			"%d running, %d cached",
+			s.counter("Threads_running"),
+			s.counter("Threads_cached"),
+		)},
+		{"Queries per second", fmt.Sprintf("%.1f", qps)},
+		{"Buffer pool hit rate", hitRate},
+		{"Slow queries", fmt.Sprintf("%d%s", s.counter("Slow_queries"), s.deltaText("Slow_queries"))},
+		{"Aborted connects", fmt.Sprintf(
+			"%d%s",
+			s.counter("Aborted_connects"),
+			s.deltaText("Aborted_connects"),
+		)},
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&sb, "[yellow]%-22s[-] %s\n", line[0], line[1])
+	}
+
+	s.dashboard.SetText(strings.TrimSuffix(sb.String(), "\n"))
+	s.dashboard.SetTitle(fmt.Sprintf(
+		"Server status - refreshed %s, every %s",
+		s.refreshedAt.Format("15:04:05"),
+		statusRefreshInterval,
+	))
+}
+
+func (s *Status) renderVariables() {
+	filter := strings.ToLower(strings.TrimSpace(s.filter.GetText()))
+
+	names := make([]string, 0, len(s.variables))
+	for name, value := range s.variables {
+		if filter == "" ||
+			strings.Contains(strings.ToLower(name), filter) ||
+			strings.Contains(strings.ToLower(value), filter) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	s.variablesTable.Clear()
+
+	for i, header := range []string{"Variable", "Value"} {
+		s.variablesTable.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, name := range names {
+		s.variablesTable.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(name)))
+		s.variablesTable.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(s.variables[name])).
+			SetExpansion(1))
+	}
+
+	s.variablesTable.ScrollToBeginning()
+}
+
+func (s *Status) counter(name string) int64 {
+	value, _ := strconv.ParseInt(s.status[name], 10, 64)
+
+	return value
+}
+
+// delta is the change of the counter since the previous refresh
+func (s *Status) delta(name string) int64 {
+	if s.previous == nil {
+		return 0
+	}
+
+	previous, _ := strconv.ParseInt(s.previous[name], 10, 64)
+
+	return s.counter(name) - previous
+}
+
+// deltaText renders the change of a counter, e.g. (+3), or nothing if it
+// didn't change
+func (s *Status) deltaText(name string) string {
+	delta := s.delta(name)
+	if delta == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" [green](%+d)[-]", delta)
+}
+
+// formatUptime formats seconds as e.g. 12d 3h 4m
+func formatUptime(seconds int64) string {
+	duration := time.Duration(seconds) * time.Second
+
+	days := int64(duration.Hours()) / 24
+	hours := int64(duration.Hours()) % 24
+	minutes := int64(duration.Minutes()) % 60
+
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+
+	return fmt.Sprintf("%dm %ds", minutes, seconds%60)
+}
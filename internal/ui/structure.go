@@ -3,8 +3,10 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/atotto/clipboard"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -20,6 +22,8 @@ type Structure struct {
 	columnFilter *tview.InputField
 	columnsTable *tview.Table
 	indexesTable *tview.Table
+	ddlView      *tview.TextView
+	ddl          string
 }
 
 func NewStructure(
@@ -45,18 +49,36 @@ func NewStructure(
 	indexesTable := tview.NewTable()
 	indexesTable.SetBorder(false)
 
+	// Setup DDL view
+	ddlView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	ddlView.SetBorder(true).
+		SetTitle("DDL")
+
+	topView := tview.NewFlex().
+		AddItem(columnsView, 0, 3, true).
+		AddItem(ddlView, 0, 2, false)
+
+	legend := tview.NewTextView().
+		SetText("[a] Add column  [e] Edit  [r] Rename  [x] Drop column/index  [i] Add index  [Y] Yank DDL").
+		SetTextColor(tcell.ColorYellow)
+
 	view := tview.NewFlex()
 	view.SetBorder(false)
 	view.SetDirection(tview.FlexRow)
-	view.AddItem(columnsView, 0, 4, true)
+	view.AddItem(topView, 0, 4, true)
 	view.AddItem(indexesTable, 0, 1, false)
+	view.AddItem(legend, 1, 1, false)
 
 	structure := &Structure{
 		app:          app,
 		view:         view,
 		db:           db,
+		columnsView:  columnsView,
 		columnsTable: columnsTable,
 		indexesTable: indexesTable,
+		ddlView:      ddlView,
 		columnFilter: columnFilter,
 	}
 
@@ -107,7 +129,10 @@ func (s *Structure) Render(table string, dbColumns []db.Column) error {
 		s.columnsTable.SetCell(
 			i+1,
 			0,
-			tview.NewTableCell(col.Name).SetAlign(tview.AlignLeft).SetSelectable(true),
+			tview.NewTableCell(col.Name).
+				SetAlign(tview.AlignLeft).
+				SetSelectable(true).
+				SetReference(col),
 		)
 		s.columnsTable.SetCell(
 			i+1,
@@ -144,10 +169,20 @@ func (s *Structure) Render(table string, dbColumns []db.Column) error {
 	s.columnsTable.Select(0, 0)
 
 	s.RenderIndexesTable(table)
+	s.RenderDDL(table)
 
 	return nil
 }
 
+func (s *Structure) Clear() {
+	s.tableName = ""
+	s.dbColumns = nil
+	s.ddl = ""
+	s.columnsTable.Clear()
+	s.indexesTable.Clear()
+	s.ddlView.Clear()
+}
+
 func (s *Structure) RenderIndexesTable(table string) error {
 	indexes, err := s.db.GetIndexes(table)
 	if err != nil {
@@ -175,15 +210,82 @@ func (s *Structure) RenderIndexesTable(table string) error {
 	return nil
 }
 
+// RenderDDL shows the CREATE TABLE statement of the table
+func (s *Structure) RenderDDL(table string) error {
+	ddl, err := s.db.GetCreateTable(table)
+	if err != nil {
+		s.ddl = ""
+		s.ddlView.SetText(fmt.Sprintf("[red]%s", tview.Escape(err.Error())))
+		return err
+	}
+
+	s.ddl = ddl
+	s.ddlView.SetText(highlightSQL(ddl))
+	s.ddlView.ScrollToBeginning()
+
+	return nil
+}
+
+// yankDDL copies the DDL to the clipboard
+func (s *Structure) yankDDL() {
+	if s.ddl == "" {
+		return
+	}
+
+	clipboard.WriteAll(s.ddl)
+
+	// On yank, highlight the DDL view for a short time
+	s.ddlView.SetBackgroundColor(tcell.ColorYellow)
+
+	time.AfterFunc(75*time.Millisecond, func() {
+		s.ddlView.SetBackgroundColor(tcell.ColorDefault)
+		s.app.Draw()
+	})
+}
+
+func (s *Structure) SetBorderColor(color tcell.Color) {
+	s.columnsView.SetBorderColor(color)
+	s.indexesTable.SetBorderColor(color)
+	s.ddlView.SetBorderColor(color)
+}
+
 func (s *Structure) setKeyBindings() {
 	s.app.SetFocus(s.columnsTable)
 
 	s.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Let the filter field handle its own input
+		if s.app.GetFocus() == s.columnFilter {
+			return event
+		}
+
 		if event.Key() == tcell.KeyRune {
 			switch event.Rune() {
-			case '2':
-				s.results.view.SwitchToPage("results")
-				s.results.app.SetFocus(s.results.resultsTable)
+			case 'Y':
+				s.yankDDL()
+				return nil
+			case 'a':
+				s.showColumnForm(nil)
+				return nil
+			case 'e':
+				if column, ok := s.selectedColumn(); ok {
+					s.showColumnForm(&column)
+				}
+				return nil
+			case 'r':
+				if column, ok := s.selectedColumn(); ok {
+					s.showRenameColumnForm(column)
+				}
+				return nil
+			case 'x':
+				if s.app.GetFocus() == s.indexesTable {
+					s.dropSelectedIndex()
+				} else if column, ok := s.selectedColumn(); ok {
+					s.dropColumn(column)
+				}
+				return nil
+			case 'i':
+				s.showIndexForm()
+				return nil
 			case '/', 'f', 'w':
 				s.app.SetFocus(s.columnFilter)
 				return nil // prevents adding the char to the input field
@@ -207,4 +309,13 @@ func (s *Structure) setKeyBindings() {
 
 		s.app.SetFocus(s.columnsTable)
 	})
+
+	s.ddlView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == 'y' {
+			s.yankDDL()
+			return nil
+		}
+
+		return event
+	})
 }
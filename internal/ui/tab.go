@@ -1,20 +1,37 @@
 package ui
 
 import (
+	"fmt"
+	"strconv"
+
+	"github.com/alfonzm/lazydb/internal/config"
 	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
 type Tab struct {
-	dbClient  *db.DBClient
-	name      string
-	lastFocus tview.Primitive
-	pages     *tview.Pages
-	app       *App
+	id             int
+	dbClient       *db.DBClient
+	connectionName string
+	connection     config.Connection
+	database       string
+	name           string
+	customName     string
+	lastFocus      tview.Primitive
+	pages          *tview.Pages
+	app            *App
+
+	// restored when the tab is first shown, so starting with many saved
+	// tabs doesn't open all their connections at once
+	pendingSession *config.TabSession
 
-	sidebar     *Sidebar
-	results     *Results
-	connections *Connections
+	sidebar        *Sidebar
+	results        *Results
+	panels         *tview.Flex
+	split          *Split
+	connections    *Connections
+	databasePicker *DatabasePicker
 }
 
 func NewTab(app *App, dbClient *db.DBClient) (*Tab, error) {
@@ -31,21 +48,98 @@ func NewTab(app *App, dbClient *db.DBClient) (*Tab, error) {
 
 	tab.connections = conns
 
+	databasePicker, err := NewDatabasePicker(tab)
+	if err != nil {
+		return nil, err
+	}
+
+	tab.databasePicker = databasePicker
+
 	tab.pages.AddPage("connections", conns.view, true, true)
 
 	return tab, nil
 }
 
-func (t *Tab) ConnectDatabase(url string, dbName string) error {
-	db, err := db.NewDBClient(url)
+func (t *Tab) ConnectDatabase(name string, connection config.Connection) error {
+	db, err := t.app.pools.Acquire(name, connection)
 	if err != nil {
 		return err
 	}
 
+	if t.dbClient != nil {
+		t.app.pools.Release(t.dbClient)
+	}
+
+	t.dbClient = db
+	t.connectionName = name
+	t.connection = connection
+	t.database = connection.Database
+
+	// Connections without a database let the user pick one after connecting
+	if connection.Database == "" {
+		t.CloseSplit()
+
+		if t.results != nil {
+			t.results.Stop()
+		}
+
+		t.pages.RemovePage("main")
+		t.sidebar = nil
+		t.results = nil
+
+		t.UpdateTabName(name)
+		return t.databasePicker.Show()
+	}
+
+	if err := t.renderMain(); err != nil {
+		return err
+	}
+
+	t.UpdateTabName(name)
+
+	return nil
+}
+
+// SwitchDatabase re-points the tab to another database on the same server
+func (t *Tab) SwitchDatabase(database string) error {
+	connection := t.connection
+	connection.Database = database
+
+	db, err := t.app.pools.Acquire(t.connectionName, connection)
+	if err != nil {
+		return err
+	}
+
+	t.app.pools.Release(t.dbClient)
+	t.dbClient = db
+	t.database = database
+
+	if err := t.renderMain(); err != nil {
+		return err
+	}
+
+	t.UpdateTabName(database)
+
+	return nil
+}
+
+// renderMain (re)creates the sidebar and results for the current database
+func (t *Tab) renderMain() error {
+	db := t.dbClient
 	pages := t.pages
 
+	if t.results != nil {
+		t.results.Stop()
+	}
+
+	// the split panel shows a table of the previous database
+	t.CloseSplit()
+
 	// Setup results component
 	results, err := NewResults(t.app, pages, db)
+	if err != nil {
+		return err
+	}
 
 	// Setup sidebar components
 	sidebar, err := NewSidebar(t, t.app.Application, db, results)
@@ -53,41 +147,150 @@ func (t *Tab) ConnectDatabase(url string, dbName string) error {
 		return err
 	}
 
+	sidebar.view.SetTitle(fmt.Sprintf("Tables (%s)", t.database))
+
 	// Setup record cellEditor component
-	cellEditor, err := NewCellEditor(t.app, pages, results, db)
+	cellEditor, err := NewCellEditor(t.app, pages, "editor", results, db)
 	if err != nil {
 		return err
 	}
 
 	results.cellEditor = cellEditor
 
+	// Results and the optional split panel
+	panels := tview.NewFlex().
+		AddItem(results.view, 0, 1, false)
+
 	main := tview.NewFlex().
 		AddItem(sidebar.view, 0, 1, true).
-		AddItem(results.view, 0, 6, false)
+		AddItem(panels, 0, 6, false)
 
 	pages.AddPage("main", main, true, true)
 	pages.AddPage("editor", cellEditor.view, true, false)
 
 	t.sidebar = sidebar
 	t.results = results
+	t.panels = panels
+
+	if t.connection.Color != "" {
+		color := tcell.GetColor(t.connection.Color)
+		sidebar.SetBorderColor(color)
+		results.SetBorderColor(color)
+	}
 
 	// Switch to main page
 	pages.SwitchToPage("main")
-	t.app.SetFocus(sidebar.list)
-
-	t.UpdateTabName(dbName)
+	t.app.SetFocus(sidebar.tree)
 
 	return nil
 }
 
+// ShowDatabasePicker lists the databases on the server to switch to
+func (t *Tab) ShowDatabasePicker() {
+	if t.dbClient == nil {
+		return
+	}
+
+	if err := t.databasePicker.Show(); err != nil {
+		t.app.ShowError(fmt.Sprintf("%v", err))
+	}
+}
+
+func (t *Tab) ReadOnly() bool {
+	return t.dbClient != nil && t.dbClient.ReadOnly()
+}
+
 func (t *Tab) OnActivate() {
+	if t.pendingSession != nil {
+		session := *t.pendingSession
+		t.pendingSession = nil
+
+		if err := t.restoreSession(session); err != nil {
+			t.app.ShowError(fmt.Sprintf("%v", err))
+		}
+
+		return
+	}
+
 	if t.lastFocus != nil {
 		t.app.SetFocus(t.lastFocus)
 	}
+
+	if t.results != nil {
+		t.results.Start()
+	}
+
+	if t.split != nil {
+		t.split.results.Start()
+	}
 }
 
 func (t *Tab) OnDeactivate() {
 	t.lastFocus = t.app.GetFocus()
+
+	if t.results != nil {
+		t.results.Stop()
+	}
+
+	if t.split != nil {
+		t.split.results.Stop()
+	}
+}
+
+// Session returns the state of the tab to restore on the next start
+func (t *Tab) Session() config.TabSession {
+	// never shown since it was restored, keep it as it was
+	if t.pendingSession != nil {
+		return *t.pendingSession
+	}
+
+	var session config.TabSession
+	if t.results != nil {
+		session = t.results.Session()
+	}
+
+	session.Name = t.customName
+	session.Connection = t.connectionName
+	session.Database = t.database
+
+	return session
+}
+
+// restoreSession reconnects and shows the table, filter, sort and page of a
+// saved tab
+func (t *Tab) restoreSession(session config.TabSession) error {
+	if session.Connection == "" {
+		return nil
+	}
+
+	connection, err := config.GetConnection(session.Connection)
+	if err != nil {
+		return err
+	}
+
+	if session.Database != "" {
+		connection.Database = session.Database
+	}
+
+	if err := t.ConnectDatabase(session.Connection, connection); err != nil {
+		return err
+	}
+
+	// e.g. the database picker is shown
+	if t.results == nil {
+		return nil
+	}
+
+	if err := t.results.Restore(session); err != nil {
+		return err
+	}
+
+	if session.Table != "" {
+		t.sidebar.selectTableNode(session.Table)
+		t.UpdateTabName(session.Table)
+	}
+
+	return nil
 }
 
 func (t *Tab) OnPressTab() {
@@ -95,20 +298,47 @@ func (t *Tab) OnPressTab() {
 		return
 	}
 
+	// sidebar, Results, then the split panel
+	if t.split != nil {
+		switch t.app.GetFocus() {
+		case t.results.resultsTable:
+			t.split.results.Focus()
+			return
+		case t.split.results.resultsTable:
+			t.app.SetFocus(t.sidebar.tree)
+			return
+		}
+	}
+
 	switch t.app.GetFocus() {
-	case t.sidebar.list:
+	case t.sidebar.tree:
 		t.results.Focus()
 	case t.results.resultsTable:
-		t.app.SetFocus(t.sidebar.list)
+		t.app.SetFocus(t.sidebar.tree)
 	case t.results.structure.columnsTable:
 		t.app.SetFocus(t.sidebar.results.structure.indexesTable)
 	case t.results.structure.indexesTable:
-		t.app.SetFocus(t.sidebar.list)
+		t.app.SetFocus(t.results.structure.ddlView)
+	case t.results.structure.ddlView:
+		t.app.SetFocus(t.sidebar.tree)
+	case t.results.users.usersTable:
+		t.app.SetFocus(t.results.users.grantsTable)
+	case t.results.processes.table, t.results.status.variablesTable, t.results.users.grantsTable:
+		t.app.SetFocus(t.sidebar.tree)
 	}
 }
 
+func (t *Tab) ShowConnections() {
+	if err := t.connections.Render(); err != nil {
+		t.app.ShowError(fmt.Sprintf("%v", err))
+	}
+
+	t.pages.SwitchToPage("connections")
+	t.app.SetFocus(t.connections.list)
+}
+
 func (t *Tab) FocusFindTable() {
-	t.app.SetFocus(t.sidebar.list)
+	t.app.SetFocus(t.sidebar.tree)
 	t.app.SetFocus(t.sidebar.filter)
 }
 
@@ -116,3 +346,37 @@ func (t *Tab) UpdateTabName(name string) {
 	t.name = name
 	t.app.RenderTabHeaders()
 }
+
+// Title is the name shown in the tab header, the name set by the user or
+// the connection and the table or database
+func (t *Tab) Title() string {
+	if t.customName != "" {
+		return t.customName
+	}
+
+	if t.connectionName == "" || t.name == "" || t.name == t.connectionName {
+		return t.name
+	}
+
+	return fmt.Sprintf("%s: %s", t.connectionName, t.name)
+}
+
+// pageName is the name of the tab in App.tabPages, it doesn't change when
+// tabs are closed or moved
+func (t *Tab) pageName() string {
+	return strconv.Itoa(t.id)
+}
+
+// Close stops the auto refresh of the pages and releases the connection
+func (t *Tab) Close() {
+	t.CloseSplit()
+
+	if t.results != nil {
+		t.results.Stop()
+	}
+
+	if t.dbClient != nil {
+		t.app.pools.Release(t.dbClient)
+		t.dbClient = nil
+	}
+}
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Actions on the selected table of the sidebar. They are destructive or
+// create tables, so the user has to type the table name to confirm.
+
+func (s *Sidebar) showTableActions() {
+	if s.db.ReadOnly() {
+		s.tab.app.ShowError("Connection is read-only, tables can't be changed")
+		return
+	}
+
+	object, ok := s.currentObject()
+	if !ok || object.Type != db.ObjectTable {
+		return
+	}
+
+	table := object.Name
+
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		AddItem("Truncate", "", 't', func() {
+			s.closeTableActions()
+			s.truncateTable(table)
+		}).
+		AddItem("Drop", "", 'd', func() {
+			s.closeTableActions()
+			s.dropTable(table)
+		}).
+		AddItem("Rename", "", 'r', func() {
+			s.closeTableActions()
+			s.showTableNameForm(fmt.Sprintf("Rename table %s", table), table, func(newName string) {
+				s.renameTable(table, newName)
+			})
+		}).
+		AddItem("Duplicate structure", "", 'c', func() {
+			s.closeTableActions()
+			s.showTableNameForm(fmt.Sprintf("Duplicate table %s", table), table+"_copy", func(newName string) {
+				s.duplicateTable(table, newName, false)
+			})
+		}).
+		AddItem("Duplicate structure and data", "", 'C', func() {
+			s.closeTableActions()
+			s.showTableNameForm(fmt.Sprintf("Duplicate table %s", table), table+"_copy", func(newName string) {
+				s.duplicateTable(table, newName, true)
+			})
+		})
+	list.SetBorder(true).
+		SetTitle(table)
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			s.closeTableActions()
+			return nil
+		case event.Rune() == 'j':
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case event.Rune() == 'k':
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		}
+
+		return event
+	})
+
+	s.tab.app.ShowModal("table-actions", list, 40, list.GetItemCount()+2)
+}
+
+func (s *Sidebar) closeTableActions() {
+	s.tab.app.CloseModal("table-actions")
+}
+
+// showTableNameForm asks for the name of the renamed or duplicated table
+func (s *Sidebar) showTableNameForm(title string, name string, onSubmit func(newName string)) {
+	form := newModalForm(s.tab.app, "table-name", title)
+	form.AddInputField("New name", name, 40, nil, nil)
+
+	form.AddButton("OK", func() {
+		newName := formText(form, "New name")
+		if newName == "" {
+			s.tab.app.ShowError("Table name is required")
+			return
+		}
+
+		s.tab.app.CloseModal("table-name")
+		onSubmit(newName)
+	})
+	form.AddButton("Cancel", func() {
+		s.tab.app.CloseModal("table-name")
+	})
+
+	s.tab.app.ShowModal("table-name", form, 60, 7)
+}
+
+func (s *Sidebar) truncateTable(table string) {
+	statement := db.TruncateTableSQL(table)
+
+	s.confirmTableStatements(fmt.Sprintf("Truncate table %s", table), table, []string{statement}, func() {
+		if s.results.selectedTable == table {
+			s.results.RefreshTable()
+		}
+	})
+}
+
+func (s *Sidebar) dropTable(table string) {
+	statement := db.DropTableSQL(table)
+
+	s.confirmTableStatements(fmt.Sprintf("Drop table %s", table), table, []string{statement}, func() {
+		if s.results.selectedTable == table {
+			s.results.Clear()
+		}
+
+		if err := s.Refresh(); err != nil {
+			s.tab.app.ShowError(fmt.Sprintf("%v", err))
+		}
+	})
+}
+
+func (s *Sidebar) renameTable(table string, newName string) {
+	statement := db.RenameTableSQL(table, newName)
+
+	s.confirmTableStatements(fmt.Sprintf("Rename table %s", table), table, []string{statement}, func() {
+		s.showCreatedTable(newName)
+	})
+}
+
+func (s *Sidebar) duplicateTable(table string, newName string, withData bool) {
+	statements := db.DuplicateTableSQL(table, newName, withData)
+
+	s.confirmTableStatements(fmt.Sprintf("Duplicate table %s", table), table, statements, func() {
+		s.showCreatedTable(newName)
+	})
+}
+
+// confirmTableStatements shows the statements and runs them once the user
+// typed the table name. onDone also runs when a statement failed since
+// earlier statements may have changed the database.
+func (s *Sidebar) confirmTableStatements(title string, table string, statements []string, onDone func()) {
+	text := ""
+	for _, statement := range statements {
+		text += highlightSQL(statement) + ";\n"
+	}
+
+	s.tab.app.ConfirmTyped(title, text, table, func() {
+		for _, statement := range statements {
+			if err := s.db.Exec(statement); err != nil {
+				onDone()
+				s.tab.app.ShowError(fmt.Sprintf("%v", err))
+				return
+			}
+		}
+
+		onDone()
+	})
+}
+
+// showCreatedTable refreshes the sidebar and selects the renamed or
+// duplicated table
+func (s *Sidebar) showCreatedTable(table string) {
+	if err := s.Refresh(); err != nil {
+		s.tab.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	s.focusObject(db.DBObject{Name: table, Type: db.ObjectTable})
+}
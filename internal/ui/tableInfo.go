@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Table metadata in the sidebar: an optional info column with the row
+// estimate and size, a details popup and sorting by size.
+
+// loadTableInfo fetches the metadata if the info column or the sort by size
+// needs it
+func (s *Sidebar) loadTableInfo() error {
+	if !s.showInfo && !s.sortBySize {
+		return nil
+	}
+
+	tableInfo, err := s.db.GetTableInfo()
+	if err != nil {
+		return err
+	}
+
+	s.tableInfo = tableInfo
+
+	return nil
+}
+
+func (s *Sidebar) toggleInfo() {
+	s.showInfo = !s.showInfo
+	s.refreshTableInfo()
+}
+
+func (s *Sidebar) toggleSortBySize() {
+	s.sortBySize = !s.sortBySize
+	s.refreshTableInfo()
+}
+
+func (s *Sidebar) refreshTableInfo() {
+	if err := s.loadTableInfo(); err != nil {
+		s.tab.app.ShowError(fmt.Sprintf("%v", err))
+	}
+
+	s.renderTree(s.filter.GetText())
+}
+
+// sortedObjects returns the objects, largest tables first when sorting by size
+func (s *Sidebar) sortedObjects() []db.DBObject {
+	if !s.sortBySize {
+		return s.objects
+	}
+
+	objects := slices.Clone(s.objects)
+	slices.SortStableFunc(objects, func(a, b db.DBObject) int {
+		return cmp.Compare(s.tableInfo[b.Name].Size(), s.tableInfo[a.Name].Size())
+	})
+
+	return objects
+}
+
+// infoText is the info column of a table, e.g. ~1.2k 64K
+func (s *Sidebar) infoText(object db.DBObject) string {
+	info, ok := s.tableInfo[object.Name]
+	if !ok || object.Type != db.ObjectTable {
+		return ""
+	}
+
+	return fmt.Sprintf(" [gray]~%s %s", formatCount(info.Rows.Int64), formatSize(info.Size()))
+}
+
+// showTableInfo shows the metadata of the selected table or view in a popup
+func (s *Sidebar) showTableInfo() {
+	object, ok := s.currentObject()
+	if !ok || (object.Type != db.ObjectTable && object.Type != db.ObjectView) {
+		return
+	}
+
+	tableInfo, err := s.db.GetTableInfo()
+	if err != nil {
+		s.tab.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	info, ok := tableInfo[object.Name]
+	if !ok {
+		s.tab.app.ShowError(fmt.Sprintf("No metadata found for %s", object.Name))
+		return
+	}
+
+	lines := [][2]string{
+		{"Engine", nullString(info.Engine)},
+		{"Rows (estimate)", nullCount(info.Rows)},
+		{"Data size", nullSize(info.DataLength)},
+		{"Index size", nullSize(info.IndexLength)},
+		{"Auto increment", nullCount(info.AutoIncrement)},
+		{"Collation", nullString(info.Collation)},
+		{"Created", nullString(info.CreateTime)},
+		{"Updated", nullString(info.UpdateTime)},
+		{"Comment", nullString(info.Comment)},
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&sb, "[yellow]%-16s[-] %s\n", line[0], tview.Escape(line[1]))
+	}
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(strings.TrimSuffix(sb.String(), "\n"))
+	textView.SetBorder(true).
+		SetTitle(fmt.Sprintf("%s %s", object.Type, object.Name))
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' || event.Rune() == 'I' {
+			s.tab.app.CloseModal("table-info")
+			return nil
+		}
+
+		return event
+	})
+
+	s.tab.app.ShowModal("table-info", textView, 60, len(lines)+2)
+}
+
+func nullString(value sql.NullString) string {
+	if !value.Valid || value.String == "" {
+		return "-"
+	}
+
+	return value.String
+}
+
+func nullCount(value sql.NullInt64) string {
+	if !value.Valid {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d", value.Int64)
+}
+
+func nullSize(value sql.NullInt64) string {
+	if !value.Valid {
+		return "-"
+	}
+
+	return formatSize(value.Int64)
+}
+
+// formatSize formats a number of bytes, e.g. 512B, 64K, 1.5M
+func formatSize(bytes int64) string {
+	units := []string{"B", "K", "M", "G", "T"}
+
+	size := float64(bytes)
+	unit := 0
+
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+
+	if unit == 0 || size >= 10 {
+		return fmt.Sprintf("%.0f%s", size, units[unit])
+	}
+
+	return fmt.Sprintf("%.1f%s", size, units[unit])
+}
+
+// formatCount formats a number of rows, e.g. 950, 1.2k, 3M
+func formatCount(count int64) string {
+	switch {
+	case count >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB", float64(count)/1_000_000_000)
+	case count >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(count)/1_000_000)
+	case count >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(count)/1_000)
+	}
+
+	return fmt.Sprintf("%d", count)
+}
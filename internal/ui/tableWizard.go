@@ -0,0 +1,426 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// TableWizard is a form to create a new table, with a live preview of the
+// CREATE TABLE statement
+type TableWizard struct {
+	sidebar      *Sidebar
+	app          *App
+	view         *tview.Flex
+	form         *tview.Form
+	columnsTable *tview.Table
+	indexesTable *tview.Table
+	preview      *tview.TextView
+	table        db.TableDefinition
+}
+
+func NewTableWizard(sidebar *Sidebar) *TableWizard {
+	form := tview.NewForm().
+		SetHorizontal(true).
+		SetFieldBackgroundColor(tcell.Color237)
+	form.SetBorder(true).
+		SetTitle("Table")
+
+	columnsTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	columnsTable.SetBorder(true).
+		SetTitle("Columns")
+
+	indexesTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	indexesTable.SetBorder(true).
+		SetTitle("Indexes")
+
+	preview := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	preview.SetBorder(true).
+		SetTitle("Preview")
+
+	legend := tview.NewTextView().
+		SetText("[a] Add  [e] Edit  [x] Remove  [p] Toggle primary key  [i] Add index  [Tab] Next  [Ctrl+S] Create  [Esc] Cancel").
+		SetTextColor(tcell.ColorYellow)
+
+	left := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 5, 0, true).
+		AddItem(columnsTable, 0, 3, false).
+		AddItem(indexesTable, 0, 1, false)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().
+			AddItem(left, 0, 3, true).
+			AddItem(preview, 0, 2, false), 0, 1, true).
+		AddItem(legend, 1, 0, false)
+	view.SetBorder(true).
+		SetTitle("Create table")
+
+	wizard := &TableWizard{
+		sidebar:      sidebar,
+		app:          sidebar.tab.app,
+		view:         view,
+		form:         form,
+		columnsTable: columnsTable,
+		indexesTable: indexesTable,
+		preview:      preview,
+		table: db.TableDefinition{
+			Columns: []db.ColumnDefinition{
+				{Name: "id", Type: "int unsigned", AutoIncrement: true},
+			},
+			PrimaryKey: []string{"id"},
+			Engine:     "InnoDB",
+			Charset:    "utf8mb4",
+		},
+	}
+
+	form.AddInputField("Name", "", 20, nil, wizard.onFormChanged).
+		AddInputField("Engine", wizard.table.Engine, 8, nil, wizard.onFormChanged).
+		AddInputField("Charset", wizard.table.Charset, 8, nil, wizard.onFormChanged).
+		AddInputField("Collation", "", 16, nil, wizard.onFormChanged)
+
+	wizard.setKeyBindings()
+	wizard.render()
+
+	return wizard
+}
+
+func (w *TableWizard) setKeyBindings() {
+	w.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlS:
+			w.create()
+			return nil
+		case tcell.KeyEscape:
+			w.close()
+			return nil
+		case tcell.KeyTab:
+			w.focusNext()
+			return nil
+		}
+
+		return event
+	})
+
+	w.columnsTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEnter {
+			w.showColumnForm(w.selectedIndex(w.columnsTable))
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'a':
+			w.showColumnForm(-1)
+		case 'e':
+			w.showColumnForm(w.selectedIndex(w.columnsTable))
+		case 'x':
+			w.removeColumn(w.selectedIndex(w.columnsTable))
+		case 'p':
+			w.togglePrimaryKey(w.selectedIndex(w.columnsTable))
+		case 'i':
+			w.showIndexForm()
+		default:
+			return event
+		}
+
+		return nil
+	})
+
+	w.indexesTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'a', 'i':
+			w.showIndexForm()
+		case 'x':
+			if i := w.selectedIndex(w.indexesTable); i >= 0 {
+				w.table.Indexes = slices.Delete(w.table.Indexes, i, i+1)
+				w.render()
+			}
+		default:
+			return event
+		}
+
+		return nil
+	})
+}
+
+// focusNext cycles the focus between the form fields, columns and indexes
+func (w *TableWizard) focusNext() {
+	switch w.app.GetFocus() {
+	case w.columnsTable:
+		w.app.SetFocus(w.indexesTable)
+	case w.indexesTable:
+		w.form.SetFocus(0)
+		w.app.SetFocus(w.form)
+	default:
+		item, _ := w.form.GetFocusedItemIndex()
+		if item < w.form.GetFormItemCount()-1 {
+			w.form.SetFocus(item + 1)
+			w.app.SetFocus(w.form)
+			return
+		}
+
+		w.app.SetFocus(w.columnsTable)
+	}
+}
+
+func (w *TableWizard) onFormChanged(string) {
+	w.table.Name = formText(w.form, "Name")
+	w.table.Engine = formText(w.form, "Engine")
+	w.table.Charset = formText(w.form, "Charset")
+	w.table.Collation = formText(w.form, "Collation")
+
+	w.renderPreview()
+}
+
+func (w *TableWizard) render() {
+	w.columnsTable.Clear()
+
+	for i, header := range []string{"Name", "Type", "Null", "Default", "Extra", "Key"} {
+		w.columnsTable.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, column := range w.table.Columns {
+		null := "NO"
+		if column.Nullable {
+			null = "YES"
+		}
+
+		extra := column.Extra
+		if column.AutoIncrement {
+			extra = strings.TrimSpace("auto_increment " + extra)
+		}
+
+		key := ""
+		if slices.Contains(w.table.PrimaryKey, column.Name) {
+			key = "PRI"
+		}
+
+		for j, text := range []string{column.Name, column.Type, null, column.Default, extra, key} {
+			w.columnsTable.SetCell(i+1, j, tview.NewTableCell(tview.Escape(text)))
+		}
+	}
+
+	w.indexesTable.Clear()
+
+	for i, header := range []string{"Name", "Columns", "Unique"} {
+		w.indexesTable.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, index := range w.table.Indexes {
+		unique := "NO"
+		if index.Unique {
+			unique = "YES"
+		}
+
+		for j, text := range []string{index.Name, strings.Join(index.Columns, ", "), unique} {
+			w.indexesTable.SetCell(i+1, j, tview.NewTableCell(tview.Escape(text)))
+		}
+	}
+
+	w.renderPreview()
+}
+
+func (w *TableWizard) renderPreview() {
+	w.preview.SetText(highlightSQL(db.CreateTableSQL(w.table)))
+}
+
+// selectedIndex returns the index of the selected row in the columns or
+// indexes slice, or -1 when the table is empty
+func (w *TableWizard) selectedIndex(table *tview.Table) int {
+	row, _ := table.GetSelection()
+	if row < 1 || row >= table.GetRowCount() {
+		return -1
+	}
+
+	return row - 1
+}
+
+// showColumnForm shows a form to edit the column at index i, or to add a
+// column after the selected one when i is -1
+func (w *TableWizard) showColumnForm(i int) {
+	definition := db.ColumnDefinition{Type: "varchar(255)", Nullable: true}
+	title := "Add column"
+
+	if i >= 0 {
+		definition = w.table.Columns[i]
+		title = fmt.Sprintf("Edit column %s", definition.Name)
+	}
+
+	form := newModalForm(w.app, "table-column", title)
+	addColumnFields(form, definition, true)
+	form.AddCheckbox("Primary key", slices.Contains(w.table.PrimaryKey, definition.Name), nil)
+
+	form.AddButton("Save", func() {
+		oldName := definition.Name
+		column := columnFromForm(form, definition)
+
+		if column.Name == "" || column.Type == "" {
+			w.app.ShowError("Column name and type are required")
+			return
+		}
+
+		for j, other := range w.table.Columns {
+			if j != i && strings.EqualFold(other.Name, column.Name) {
+				w.app.ShowError(fmt.Sprintf("Column %s already exists", column.Name))
+				return
+			}
+		}
+
+		if i >= 0 {
+			w.table.Columns[i] = column
+		} else {
+			position := w.selectedIndex(w.columnsTable) + 1
+			if position == 0 {
+				position = len(w.table.Columns)
+			}
+			w.table.Columns = slices.Insert(w.table.Columns, position, column)
+			i = position
+		}
+
+		// keep the position of the column in a composite primary key
+		key := slices.Index(w.table.PrimaryKey, oldName)
+		primary := formChecked(form, "Primary key")
+
+		switch {
+		case key >= 0 && primary:
+			w.table.PrimaryKey[key] = column.Name
+		case key >= 0:
+			w.table.PrimaryKey = slices.Delete(w.table.PrimaryKey, key, key+1)
+		case primary:
+			w.table.PrimaryKey = append(w.table.PrimaryKey, column.Name)
+		}
+
+		w.app.CloseModal("table-column")
+		w.render()
+		w.columnsTable.Select(i+1, 0)
+	})
+	form.AddButton("Cancel", func() {
+		w.app.CloseModal("table-column")
+	})
+
+	w.app.ShowModal("table-column", form, 70, form.GetFormItemCount()*2+5)
+}
+
+func (w *TableWizard) removeColumn(i int) {
+	if i < 0 {
+		return
+	}
+
+	name := w.table.Columns[i].Name
+
+	w.table.Columns = slices.Delete(w.table.Columns, i, i+1)
+	w.table.PrimaryKey = slices.DeleteFunc(w.table.PrimaryKey, func(column string) bool {
+		return column == name
+	})
+
+	w.render()
+}
+
+func (w *TableWizard) togglePrimaryKey(i int) {
+	if i < 0 {
+		return
+	}
+
+	name := w.table.Columns[i].Name
+
+	if slices.Contains(w.table.PrimaryKey, name) {
+		w.table.PrimaryKey = slices.DeleteFunc(w.table.PrimaryKey, func(column string) bool {
+			return column == name
+		})
+	} else {
+		w.table.PrimaryKey = append(w.table.PrimaryKey, name)
+	}
+
+	w.render()
+}
+
+// showIndexForm shows a form to add an index, prefilled with the selected column
+func (w *TableWizard) showIndexForm() {
+	columnName := ""
+	if i := w.selectedIndex(w.columnsTable); i >= 0 {
+		columnName = w.table.Columns[i].Name
+	}
+
+	form := newModalForm(w.app, "table-index", "Add index")
+	form.AddInputField("Name", "idx_"+columnName, 40, nil, nil).
+		AddInputField("Columns", columnName, 40, nil, nil).
+		AddCheckbox("Unique", false, nil)
+
+	form.GetFormItemByLabel("Columns").(*tview.InputField).
+		SetPlaceholder("comma separated, e.g. user_id, created_at")
+
+	form.AddButton("Save", func() {
+		index := db.IndexDefinition{
+			Name:    formText(form, "Name"),
+			Columns: splitList(formText(form, "Columns")),
+			Unique:  formChecked(form, "Unique"),
+		}
+
+		if index.Name == "" || len(index.Columns) == 0 {
+			w.app.ShowError("Index name and columns are required")
+			return
+		}
+
+		w.table.Indexes = append(w.table.Indexes, index)
+
+		w.app.CloseModal("table-index")
+		w.render()
+	})
+	form.AddButton("Cancel", func() {
+		w.app.CloseModal("table-index")
+	})
+
+	w.app.ShowModal("table-index", form, 70, 11)
+}
+
+// create confirms and runs the CREATE TABLE statement, then shows the new
+// table in the sidebar
+func (w *TableWizard) create() {
+	if w.table.Name == "" {
+		w.app.ShowError("Table name is required")
+		return
+	}
+
+	if len(w.table.Columns) == 0 {
+		w.app.ShowError("A table needs at least one column")
+		return
+	}
+
+	statement := db.CreateTableSQL(w.table)
+
+	w.app.Confirm(fmt.Sprintf("Create table %s", w.table.Name), highlightSQL(statement), func() {
+		if err := w.sidebar.db.Exec(statement); err != nil {
+			w.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		w.close()
+
+		if err := w.sidebar.Refresh(); err != nil {
+			w.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		w.sidebar.focusObject(db.DBObject{Name: w.table.Name, Type: db.ObjectTable})
+	})
+}
+
+func (w *TableWizard) close() {
+	w.sidebar.tab.pages.RemovePage("create-table")
+	w.app.SetFocus(w.sidebar.tree)
+}
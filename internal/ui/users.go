@@ -0,0 +1,398 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alfonzm/lazydb/internal/db"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Users lists the database users with their grants, to audit access
+type Users struct {
+	app         *App
+	db          *db.DBClient
+	view        *tview.Flex
+	filter      *tview.InputField
+	usersTable  *tview.Table
+	grantsTable *tview.Table
+	accounts    []db.Account
+	grants      map[db.Account][]string
+
+	// refreshes counts the calls to Refresh, so grants loaded by an older
+	// refresh are dropped
+	refreshes int
+}
+
+func NewUsers(app *App, db *db.DBClient) (*Users, error) {
+	filter := tview.NewInputField().
+		SetLabel("Filter ").
+		SetPlaceholder("user, privilege or object, e.g. DELETE or app.*").
+		SetFieldBackgroundColor(tcell.ColorNone)
+
+	usersTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	usersTable.SetBorder(true).
+		SetTitle("Users")
+
+	grantsTable := tview.NewTable().
+		SetSelectable(true, false)
+	grantsTable.SetBorder(true).
+		SetTitle("Grants")
+
+	legend := tview.NewTextView().
+		SetText("[n] New user  [g] Grant  [x] Revoke selected grant  [r] Refresh  [/] Filter").
+		SetTextColor(tcell.ColorYellow)
+
+	view := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, false).
+		AddItem(tview.NewFlex().
+			AddItem(usersTable, 0, 1, true).
+			AddItem(grantsTable, 0, 2, false), 0, 1, true).
+		AddItem(legend, 1, 0, false)
+
+	users := &Users{
+		app:         app,
+		db:          db,
+		view:        view,
+		filter:      filter,
+		usersTable:  usersTable,
+		grantsTable: grantsTable,
+	}
+
+	users.setKeyBindings()
+
+	return users, nil
+}
+
+func (u *Users) setKeyBindings() {
+	capture := func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape && u.filter.GetText() != "" {
+			u.filter.SetText("")
+			return nil
+		}
+
+		switch event.Rune() {
+		case '/':
+			u.app.SetFocus(u.filter)
+		case 'r':
+			u.Refresh()
+		case 'n':
+			u.showCreateUserForm()
+		case 'g':
+			u.showGrantForm()
+		case 'x':
+			u.revokeSelectedGrant()
+		default:
+			return event
+		}
+
+		return nil
+	}
+
+	u.usersTable.SetInputCapture(capture)
+	u.grantsTable.SetInputCapture(capture)
+
+	u.usersTable.SetSelectionChangedFunc(func(row, column int) {
+		u.renderGrants()
+	})
+
+	u.filter.SetChangedFunc(func(text string) {
+		u.render()
+	})
+
+	u.filter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			u.filter.SetText("")
+		}
+
+		u.app.SetFocus(u.usersTable)
+	})
+}
+
+func (u *Users) SetBorderColor(color tcell.Color) {
+	u.usersTable.SetBorderColor(color)
+	u.grantsTable.SetBorderColor(color)
+}
+
+// Refresh reloads the users, and their grants in the background since
+// there is a SHOW GRANTS query per user
+func (u *Users) Refresh() {
+	accounts, err := u.db.GetAccounts()
+	if err != nil {
+		u.app.ShowError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	u.refreshes++
+	refresh := u.refreshes
+
+	u.accounts = accounts
+	u.grants = nil
+
+	u.render()
+
+	go func() {
+		grants := map[db.Account][]string{}
+
+		for _, account := range accounts {
+			accountGrants, err := u.db.GetGrants(account)
+			if err != nil {
+				// e.g. no privilege to see the grants of other users
+				accountGrants = []string{fmt.Sprintf("-- %v", err)}
+			}
+
+			grants[account] = accountGrants
+		}
+
+		u.app.QueueUpdateDraw(func() {
+			if refresh != u.refreshes {
+				return
+			}
+
+			u.grants = grants
+			u.render()
+		})
+	}()
+}
+
+func (u *Users) render() {
+	selected, hasSelected := u.selectedAccount()
+
+	u.usersTable.Clear()
+
+	for i, header := range []string{"User", "Host", "Grants"} {
+		u.usersTable.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	row := 1
+
+	for _, account := range u.accounts {
+		grants := u.matchingGrants(account)
+		if len(grants) == 0 && !u.matchesAccount(account) {
+			continue
+		}
+
+		count := "..."
+		if u.grants != nil {
+			count = fmt.Sprintf("%d", len(u.grants[account]))
+		}
+
+		u.usersTable.SetCell(row, 0, tview.NewTableCell(tview.Escape(account.User)).SetReference(account))
+		u.usersTable.SetCell(row, 1, tview.NewTableCell(tview.Escape(account.Host)))
+		u.usersTable.SetCell(row, 2, tview.NewTableCell(count))
+
+		if hasSelected && account == selected {
+			u.usersTable.Select(row, 0)
+		}
+
+		row++
+	}
+
+	if u.grants == nil {
+		u.usersTable.SetTitle(fmt.Sprintf("Users (%d) - loading grants", row-1))
+	} else {
+		u.usersTable.SetTitle(fmt.Sprintf("Users (%d)", row-1))
+	}
+
+	u.renderGrants()
+}
+
+// renderGrants shows the grants of the selected user that match the filter
+func (u *Users) renderGrants() {
+	u.grantsTable.Clear()
+
+	account, ok := u.selectedAccount()
+	if !ok {
+		u.grantsTable.SetTitle("Grants")
+		return
+	}
+
+	if u.grants == nil {
+		u.grantsTable.SetTitle(fmt.Sprintf("Grants for %s - loading", tview.Escape(account.String())))
+		return
+	}
+
+	grants := u.matchingGrants(account)
+	if u.matchesAccount(account) {
+		grants = u.grants[account]
+	}
+
+	for i, grant := range grants {
+		u.grantsTable.SetCell(i, 0, tview.NewTableCell(highlightSQL(grant)).
+			SetReference(grant).
+			SetExpansion(1))
+	}
+
+	u.grantsTable.SetTitle(fmt.Sprintf("Grants for %s", tview.Escape(account.String())))
+}
+
+func (u *Users) filterText() string {
+	return strings.ToLower(strings.TrimSpace(u.filter.GetText()))
+}
+
+// matchesAccount reports whether the user or host matches the filter
+func (u *Users) matchesAccount(account db.Account) bool {
+	filter := u.filterText()
+
+	return filter == "" || strings.Contains(strings.ToLower(account.User+"@"+account.Host), filter)
+}
+
+// matchingGrants returns the grants of the account that contain the filter,
+// e.g. a privilege or an object
+func (u *Users) matchingGrants(account db.Account) []string {
+	filter := u.filterText()
+
+	var grants []string
+
+	for _, grant := range u.grants[account] {
+		// backticks are optional when filtering by object, e.g. app.*
+		text := strings.ToLower(strings.ReplaceAll(grant, "`", ""))
+
+		if filter == "" || strings.Contains(text, strings.ReplaceAll(filter, "`", "")) {
+			grants = append(grants, grant)
+		}
+	}
+
+	return grants
+}
+
+func (u *Users) selectedAccount() (db.Account, bool) {
+	row, _ := u.usersTable.GetSelection()
+	if row < 1 || row >= u.usersTable.GetRowCount() {
+		return db.Account{}, false
+	}
+
+	account, ok := u.usersTable.GetCell(row, 0).GetReference().(db.Account)
+
+	return account, ok
+}
+
+// canEdit reports whether users and grants can be changed, showing an error if not
+func (u *Users) canEdit() bool {
+	if u.db.ReadOnly() {
+		u.app.ShowError("Connection is read-only, users and grants can't be changed")
+		return false
+	}
+
+	return true
+}
+
+func (u *Users) showCreateUserForm() {
+	if !u.canEdit() {
+		return
+	}
+
+	form := newModalForm(u.app, "user-form", "Create user")
+	form.AddInputField("User", "", 40, nil, nil).
+		AddInputField("Host", "%", 40, nil, nil).
+		AddPasswordField("Password", "", 40, '*', nil)
+
+	form.AddButton("Preview", func() {
+		account := db.Account{User: formText(form, "User"), Host: formText(form, "Host")}
+		password := form.GetFormItemByLabel("Password").(*tview.InputField).GetText()
+
+		if account.User == "" || account.Host == "" {
+			u.app.ShowError("User and host are required")
+			return
+		}
+
+		// don't show the password in the confirmation
+		text := highlightSQL(db.CreateUserSQL(account, "********"))
+
+		u.confirmStatement("Create user", text, db.CreateUserSQL(account, password), "user-form")
+	})
+	form.AddButton("Cancel", func() {
+		u.app.CloseModal("user-form")
+	})
+
+	u.app.ShowModal("user-form", form, 60, 11)
+}
+
+// showGrantForm grants privileges to the selected user, on the current
+// database by default
+func (u *Users) showGrantForm() {
+	account, ok := u.selectedAccount()
+	if !ok || !u.canEdit() {
+		return
+	}
+
+	object := "*.*"
+	if database, err := u.db.GetCurrentDatabase(); err == nil && database != "" {
+		object = db.QuoteIdentifier(database) + ".*"
+	}
+
+	form := newModalForm(u.app, "user-form", fmt.Sprintf("Grant to %s", account))
+	form.AddInputField("Privileges", "SELECT", 40, nil, nil).
+		AddInputField("On", object, 40, nil, nil)
+
+	form.GetFormItemByLabel("Privileges").(*tview.InputField).
+		SetPlaceholder("e.g. SELECT, INSERT, UPDATE or ALL PRIVILEGES")
+
+	form.AddButton("Preview", func() {
+		privileges := formText(form, "Privileges")
+		on := formText(form, "On")
+
+		if privileges == "" || on == "" {
+			u.app.ShowError("Privileges and object are required")
+			return
+		}
+
+		statement := db.GrantSQL(privileges, on, account)
+
+		u.confirmStatement("Grant privileges", highlightSQL(statement), statement, "user-form")
+	})
+	form.AddButton("Cancel", func() {
+		u.app.CloseModal("user-form")
+	})
+
+	u.app.ShowModal("user-form", form, 60, 9)
+}
+
+// revokeSelectedGrant revokes the privileges of the selected grant
+func (u *Users) revokeSelectedGrant() {
+	account, ok := u.selectedAccount()
+	if !ok || !u.canEdit() {
+		return
+	}
+
+	row, _ := u.grantsTable.GetSelection()
+	if u.app.GetFocus() != u.grantsTable || row >= u.grantsTable.GetRowCount() {
+		u.app.ShowError("Select a grant in the grants list (Tab) to revoke it")
+		return
+	}
+
+	grant, _ := u.grantsTable.GetCell(row, 0).GetReference().(string)
+
+	privileges, object, ok := db.ParseGrant(grant)
+	if !ok {
+		u.app.ShowError(fmt.Sprintf("Can't revoke %s", grant))
+		return
+	}
+
+	statement := db.RevokeSQL(privileges, object, account)
+
+	u.confirmStatement("Revoke privileges", highlightSQL(statement), statement, "")
+}
+
+// confirmStatement shows the text and runs the statement once confirmed,
+// then closes the form modal, if any
+func (u *Users) confirmStatement(title string, text string, statement string, modal string) {
+	u.app.Confirm(title, text, func() {
+		if err := u.db.Exec(statement); err != nil {
+			u.app.ShowError(fmt.Sprintf("%v", err))
+			return
+		}
+
+		if modal != "" {
+			u.app.CloseModal(modal)
+		}
+
+		u.Refresh()
+	})
+}